@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Bookmarks.
+// Ctrl+1 to Ctrl+9 puts a bookmark on the paragraph at the focus bar, and 1 to 9 jumps back to it.
+// A bookmark remembers the text of its paragraph, not where it was on screen,
+// so it stays put when the font or the width changes, or lines are added above it.
+// The bookmarks of a script are kept next to it, in a file with .bookmarks added to the name.
+
+// bookmark is a paragraph to come back to
+type bookmark struct {
+	// Where the paragraph was when the bookmark was set, to choose between paragraphs with the same text
+	Paragraph int    `json:"paragraph"`
+	Text      string `json:"text"`
+}
+
+// bookmarks are numbered 1 to 9
+type bookmarks map[int]bookmark
+
+// bookmarkActions are the actions to go to bookmark n and to set it, bookmark-n and set-bookmark-n.
+// Like all actions, their keys can be changed in the preferences.
+func bookmarkActions(n int) (goTo, set action) {
+	return action(fmt.Sprintf("bookmark-%d", n)), action(fmt.Sprintf("set-bookmark-%d", n))
+}
+
+// bookmark tells if an action goes to or sets a bookmark, and which one
+func (a action) bookmark() (n int, set bool, ok bool) {
+	name, set := strings.CutPrefix(string(a), "set-")
+	number, ok := strings.CutPrefix(name, "bookmark-")
+	if !ok {
+		return 0, false, false
+	}
+	n, err := strconv.Atoi(number)
+	return n, set, err == nil && n >= 1 && n <= 9
+}
+
+// bookmarksPath is where the bookmarks of a script are stored
+func bookmarksPath(filename string) string {
+	return filename + ".bookmarks"
+}
+
+// loadBookmarks reads the bookmarks of a script.
+// A missing file is fine, that just means there are no bookmarks yet.
+func loadBookmarks(filename string) (bookmarks, error) {
+	marks := bookmarks{}
+	if filename == "-" {
+		return marks, nil
+	}
+	f, err := os.ReadFile(bookmarksPath(filename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return marks, nil
+	}
+	if err != nil {
+		return marks, err
+	}
+	if err := json.Unmarshal(f, &marks); err != nil {
+		return bookmarks{}, fmt.Errorf("%s: %w", bookmarksPath(filename), err)
+	}
+	return marks, nil
+}
+
+// save writes the bookmarks next to the script.
+// A script from stdin has nowhere to keep them, so they last only as long as the show.
+func (b bookmarks) save(filename string) error {
+	if filename == "-" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(bookmarksPath(filename), append(data, '\n'))
+}
+
+// find looks for the paragraph a bookmark was set on.
+// The same text closest to where it was wins. If the text is gone, or was blank,
+// the bookmark falls back to the paragraph number it had.
+func (b bookmark) find(paragraphs []paragraph) int {
+	if len(paragraphs) == 0 {
+		return -1
+	}
+	best := -1
+	if strings.TrimSpace(b.Text) != "" {
+		for i, p := range paragraphs {
+			if p.text == b.Text && (best < 0 || abs(i-b.Paragraph) < abs(best-b.Paragraph)) {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		best = min(max(b.Paragraph, 0), len(paragraphs)-1)
+	}
+	return best
+}
+
+// setBookmark puts bookmark number n on the paragraph at the focus bar, and saves it
+func (p *prompter) setBookmark(n int) {
+	current := p.focus()
+	if current < 0 || current >= len(p.paragraphList) {
+		return
+	}
+	s := p.scripts[p.currentScript]
+	if s.bookmarks == nil {
+		s.bookmarks = bookmarks{}
+	}
+	s.bookmarks[n] = bookmark{Paragraph: current, Text: p.paragraphList[current].text}
+	fmt.Printf("MARK  : bookmark %d at paragraph %d\n", n, current+1)
+	if err := s.bookmarks.save(s.filename); err != nil {
+		fmt.Printf("MARK  : could not save the bookmarks: %v\n", err)
+	}
+}
+
+// goToBookmark brings the paragraph of bookmark number n to the focus bar
+func (p *prompter) goToBookmark(n int) {
+	b, ok := p.scripts[p.currentScript].bookmarks[n]
+	if !ok {
+		fmt.Printf("MARK  : no bookmark %d\n", n)
+		return
+	}
+	if i := b.find(p.paragraphList); i >= 0 {
+		p.jumpTo = i
+	}
+}
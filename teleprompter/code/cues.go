@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/unit"
+)
+
+// Cues in the script.
+// A script can tell the prompter what to do when a line reaches the focus bar:
+//
+//	[PAUSE 3s]   hold the scrolling for 3 seconds, then carry on
+//	[SPEED +2]   two steps faster, like pressing F twice. [SPEED -1] is one step slower
+//	[STOP]       stop, and wait for Space
+//	[SLOW]       half the speed, until the next line reaches the focus bar
+//
+// Cues can be on a line of their own, or anywhere in a line of text.
+// They are never shown to the talent, but the operator window can list them.
+// Anything else in brackets, like [applause], is left as text.
+
+// The kinds of cues
+type cueKind int
+
+const (
+	cuePause cueKind = iota
+	cueSpeed
+	cueStop
+	cueSlow
+)
+
+// cue is a single cue, in the paragraph where it was written
+type cue struct {
+	kind cueKind
+	// How long to hold, for a pause
+	wait time.Duration
+	// How many steps to change the speed, for a speed cue
+	steps float32
+	// The cue as written in the script
+	token string
+}
+
+// Finds anything that looks like a cue. The name decides if it is one.
+var cuePattern = regexp.MustCompile(`\[\s*([A-Za-z]+)\s*([^\]]*?)\s*\]`)
+
+// parseCues takes the cues out of a line, and returns what's left of it
+func parseCues(line string) (string, []cue) {
+	var cues []cue
+	rest := cuePattern.ReplaceAllStringFunc(line, func(token string) string {
+		match := cuePattern.FindStringSubmatch(token)
+		c, ok := parseCue(match[1], match[2])
+		if !ok {
+			return token
+		}
+		c.token = token
+		cues = append(cues, c)
+		return ""
+	})
+	if len(cues) == 0 {
+		return line, nil
+	}
+	// Removing a cue from the middle of a line leaves a double space
+	return strings.Join(strings.Fields(rest), " "), cues
+}
+
+// parseCue reads the name and argument of a cue.
+// It returns false if it isn't a cue, or a cue the prompter can't make sense of.
+func parseCue(name, arg string) (cue, bool) {
+	switch strings.ToUpper(name) {
+	case "PAUSE":
+		// Either a duration like 3s or 1m, or a number of seconds
+		wait, err := time.ParseDuration(arg)
+		if err != nil {
+			seconds, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return cue{}, false
+			}
+			wait = time.Duration(seconds * float64(time.Second))
+		}
+		return cue{kind: cuePause, wait: wait}, wait > 0
+	case "SPEED":
+		// Always a change, so the sign must be there
+		if !strings.HasPrefix(arg, "+") && !strings.HasPrefix(arg, "-") {
+			return cue{}, false
+		}
+		steps, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return cue{}, false
+		}
+		return cue{kind: cueSpeed, steps: float32(steps)}, true
+	case "STOP":
+		return cue{kind: cueStop}, arg == ""
+	case "SLOW":
+		return cue{kind: cueSlow}, arg == ""
+	}
+	return cue{}, false
+}
+
+// cueText lists the cues of a paragraph as written, for the operator
+func cueText(cues []cue) string {
+	tokens := make([]string, len(cues))
+	for i, c := range cues {
+		tokens[i] = c.token
+	}
+	return strings.Join(tokens, " ")
+}
+
+// runCues carries out the cues that reached the focus bar since last frame.
+// Only autoscroll sets off cues. When the text is moved any other way, the cues passed are skipped,
+// and the ones below the focus bar are waiting again, even if they went off before.
+func (p *prompter) runCues(now time.Time) {
+	current := p.focus()
+	// Done with a slow line?
+	if p.slowAt != current {
+		p.slowAt = -1
+	}
+	if !p.autoscroll || p.cuesMoved || current < 0 {
+		p.cuesDone, p.cuesMoved = current, false
+		return
+	}
+	for i := p.cuesDone + 1; i <= current && i < len(p.paragraphList); i++ {
+		for _, c := range p.paragraphList[i].cues {
+			fmt.Printf("CUE   : %s in line %d\n", c.token, i+1)
+			switch c.kind {
+			case cuePause:
+				p.holdUntil = now.Add(c.wait)
+			case cueSpeed:
+				p.changeSpeed(c.steps)
+			case cueStop:
+				p.autoscroll = false
+			case cueSlow:
+				p.slowAt = current
+			}
+		}
+	}
+	p.cuesDone = current
+}
+
+// changeSpeed makes autoscroll faster or slower by a number of steps, without stopping it
+func (p *prompter) changeSpeed(steps float32) {
+	if p.wpmMode {
+		p.targetWPM = max(p.targetWPM+steps*wpmStep, 0)
+	} else {
+		p.autospeed = max(p.autospeed+unit.Dp(steps)*speedStep, 0)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits for the pace the schedule may ask for. Outside these the speech is
+// either unreadable or painfully slow, and it's better to run a bit over or under.
+const (
+	minPlannedWPM float32 = 40
+	maxPlannedWPM float32 = 400
+)
+
+// schedule keeps the speech inside a fixed time slot, such as a broadcast segment.
+// The clock starts the first time autoscroll is started, and keeps running through pauses.
+// The pace is planned again every time a new paragraph reaches the focus bar,
+// so if the presenter speeds up or slows down, the plan starts over from there.
+// F and S change the pace on top of the plan, and that change is kept,
+// so the schedule doesn't fight the presenter by undoing it at the next paragraph.
+type schedule struct {
+	duration time.Duration
+	started  time.Time
+	// The paragraph under the focus bar when the pace was last planned
+	plannedAt int
+	// The presenter's own change to the pace, in words per minute
+	adjust float32
+}
+
+// newSchedule gives a schedule for a slot of the given length
+func newSchedule(duration time.Duration) *schedule {
+	return &schedule{duration: duration, plannedAt: -1}
+}
+
+// start starts the clock, unless it's running already
+func (s *schedule) start(now time.Time) {
+	if s.started.IsZero() {
+		s.started = now
+	}
+}
+
+// replan forgets the last plan, for example after the text was scrolled by hand
+func (s *schedule) replan() {
+	s.plannedAt = -1
+}
+
+// nudge changes the pace by hand, on top of the plan, and plans again right away
+func (s *schedule) nudge(wpm float32) {
+	s.adjust += wpm
+	s.replan()
+}
+
+// timeLeft is how much of the slot remains
+func (s *schedule) timeLeft(now time.Time) time.Duration {
+	if s.started.IsZero() {
+		return s.duration
+	}
+	return s.duration - now.Sub(s.started)
+}
+
+// pace returns the words per minute needed to reach the last paragraph on time,
+// plus the change made by hand. When the paragraph under the focus bar is the same as last time,
+// or the time is up, the current pace is kept.
+func (s *schedule) pace(now time.Time, paragraph int, remaining float32, current float32) float32 {
+	if paragraph < 0 || paragraph == s.plannedAt {
+		return current
+	}
+	s.plannedAt = paragraph
+	left := s.timeLeft(now)
+	if left <= time.Second || remaining <= 0 {
+		return current
+	}
+	wpm := remaining/float32(left.Minutes()) + s.adjust
+	return min(max(wpm, minPlannedWPM), maxPlannedWPM)
+}
+
+// remainingWords counts the words from the focus bar until the last paragraph with text,
+// which is where the speech should be when the time is up.
+// fraction is how far into the current paragraph the focus bar is.
+func remainingWords(paragraphs []paragraph, words []int, index int, fraction float32) float32 {
+	last := len(paragraphs) - 1
+	for last > 0 && strings.TrimSpace(paragraphs[last].spoken()) == "" {
+		last--
+	}
+	if index < 0 || index >= last {
+		return 0
+	}
+	remaining := float32(words[index]) * (1 - fraction)
+	for i := index + 1; i < last; i++ {
+		remaining += float32(words[i])
+	}
+	return remaining
+}
+
+// formatClock shows a duration as minutes and seconds, like 4:30
+func formatClock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%s%d:%02d", sign, int(d.Minutes()), int(d.Seconds())%60)
+}
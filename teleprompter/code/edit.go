@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// Edit mode, for last minute fixes without leaving the prompter.
+// E edits the paragraph at the focus bar, and Ctrl+E the whole script.
+// The text is swapped for an editor, and autoscroll stops.
+// Ctrl+S saves, and so does Enter when editing a single paragraph. Esc leaves without saving.
+// The file is saved through a temporary file, so it's never half written,
+// and the version before is kept with .bak added to the name.
+
+// editBox is the editor in a window
+type editBox struct {
+	open bool
+	// Editing the whole script, or only the paragraph at index
+	whole  bool
+	index  int
+	editor widget.Editor
+	// The line, or the whole file, as it was when the edit started.
+	// If the file changes meanwhile, we must not save over what someone else wrote.
+	original string
+}
+
+// start opens the editor, with the paragraph at the focus bar or the whole script as written in the file
+func (e *editBox) start(p *prompter, whole bool) error {
+	s := p.scripts[p.currentScript]
+	if s.filename == "-" {
+		return errors.New("a script from stdin can't be saved")
+	}
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	e.whole, e.index, e.original = whole, p.focus(), string(data)
+	e.editor.SingleLine = !whole
+	e.editor.Submit = !whole
+	if whole {
+		e.editor.SetText(string(data))
+	} else {
+		// Paragraphs are lines in the file, except for subtitles
+		if isSubtitles(s.filename) {
+			return errors.New("captions can only be edited with the whole script")
+		}
+		if e.index < 0 || e.index >= len(lines) {
+			return errors.New("no line of the script at the focus bar")
+		}
+		// Windows line endings stay as they were, and aren't shown
+		e.original = lines[e.index]
+		e.editor.SetText(strings.TrimSuffix(lines[e.index], "\r"))
+	}
+	e.open = true
+	p.autoscroll = false
+	return nil
+}
+
+// save writes the edit to the file, and shows the new text where the old was
+func (e *editBox) save(p *prompter) error {
+	s := p.scripts[p.currentScript]
+	text := e.editor.Text()
+	data, err := os.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+	if e.whole {
+		if string(data) != e.original {
+			return errors.New("the file was changed while editing, copy the edit and try again")
+		}
+	} else {
+		// Lines may have been added or taken out above, so the line is found again by its text
+		lines := strings.Split(string(data), "\n")
+		at := findLine(lines, e.original, e.index)
+		if at < 0 {
+			return errors.New("the line was changed in the file while editing")
+		}
+		e.index = at
+		if strings.HasSuffix(lines[e.index], "\r") {
+			text += "\r"
+		}
+		lines[e.index] = text
+		text = strings.Join(lines, "\n")
+	}
+	if err := saveScript(s.filename, []byte(text)); err != nil {
+		return err
+	}
+	paragraphs, err := loadText(s.filename)
+	if err != nil {
+		return err
+	}
+	e.open = false
+	if e.whole {
+		// The paragraph at the focus bar is found by its text, and stays where it was
+		p.reload(scriptReload{script: p.currentScript, paragraphs: paragraphs})
+	} else {
+		// Nothing above the paragraph moved, so the text can stay exactly where it is
+		p.setText(paragraphs)
+	}
+	fmt.Printf("EDIT  : saved %s\n", s.filename)
+	return nil
+}
+
+// findLine finds the line with the given text closest to where it was, or -1 if it's gone
+func findLine(lines []string, text string, near int) int {
+	found := -1
+	for i, line := range lines {
+		if line == text && (found < 0 || abs(i-near) < abs(found-near)) {
+			found = i
+		}
+	}
+	return found
+}
+
+// update handles Enter in the paragraph editor. It returns true if anything changed.
+func (e *editBox) update(gtx C, p *prompter) bool {
+	if !e.open {
+		return false
+	}
+	changed := false
+	for {
+		ev, ok := e.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := ev.(widget.SubmitEvent); ok {
+			e.finish(gtx, p)
+		}
+		changed = true
+	}
+	return changed
+}
+
+// finish saves and closes the editor. If saving fails, the editor stays open.
+func (e *editBox) finish(gtx C, p *prompter) {
+	if err := e.save(p); err != nil {
+		p.reloadWarning = "Save failed: " + err.Error()
+		fmt.Printf("EDIT  : %v\n", err)
+		return
+	}
+	p.reloadWarning = ""
+	gtx.Execute(key.FocusCmd{})
+}
+
+// cancel closes the editor without saving
+func (e *editBox) cancel(gtx C) {
+	e.open = false
+	gtx.Execute(key.FocusCmd{})
+}
+
+// layout draws the editor in place of the text.
+// A paragraph is edited at the focus bar, in the size of the text. The whole script is smaller.
+func (e *editBox) layout(gtx C, th *material.Theme, colors colorMode, fontSize unit.Sp, barTop int) D {
+	if !gtx.Focused(&e.editor) {
+		gtx.Execute(key.FocusCmd{Tag: &e.editor})
+	}
+	editor := material.Editor(th, &e.editor, "")
+	editor.Color = colors.foreground
+	editor.SelectionColor = colors.focusbar
+	if e.whole {
+		editor.TextSize = unit.Sp(18)
+		return layout.UniformInset(unit.Dp(8)).Layout(gtx, editor.Layout)
+	}
+	editor.TextSize = fontSize
+	return layout.Inset{Top: gtx.Metric.PxToDp(barTop)}.Layout(gtx, editor.Layout)
+}
+
+// saveScript writes a script safely.
+// The file as it was is kept as a backup, with .bak added to the name.
+func saveScript(filename string, data []byte) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	old, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename+".bak", old, info.Mode()); err != nil {
+		return fmt.Errorf("could not make a backup: %w", err)
+	}
+	return writeFileAtomic(filename, data)
+}
+
+// writeFileAtomic writes a file safely.
+// It's written to a temporary file first, which replaces the file only when it's all there,
+// so a crash never leaves half a file behind. A file that's already there keeps its permissions.
+func writeFileAtomic(filename string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".teleprompter-*.tmp")
+	if err != nil {
+		return err
+	}
+	// If anything goes wrong, the temporary file shall not be left behind
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
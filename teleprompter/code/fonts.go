@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gioui.org/font"
+	"gioui.org/font/gofont"
+	"gioui.org/font/opentype"
+	"gioui.org/text"
+	"gioui.org/widget/material"
+)
+
+// Fonts.
+// The text is in the Go fonts, unless -font gives font files, .ttf, .otf or .ttc, to use instead.
+// -font can be repeated, and the first font is the one for the text, unless -face names another,
+// like -face "Noto Serif, Noto Sans CJK JP". Faces are tried in that order.
+// Letters that none of them have, like Chinese or emoji in a Latin font, are taken from any other font
+// that has them, first the font files, then the Go fonts, then the fonts installed on the system.
+// -weight makes the text lighter or bolder. The fonts, the face and the weight are saved in the preferences,
+// the fonts with their full path. If a saved font file is gone, the prompter says so and uses the Go fonts.
+
+// The fonts loaded from files
+var fontFaces []font.FontFace
+
+// The face and weight of the text
+var textFace font.Typeface
+var textWeight font.Weight
+
+// Names of the weights, as written in -weight and the preferences
+var weightNames = map[string]font.Weight{
+	"thin":       font.Thin,
+	"extralight": font.ExtraLight,
+	"light":      font.Light,
+	"normal":     font.Normal,
+	"regular":    font.Normal,
+	"medium":     font.Medium,
+	"semibold":   font.SemiBold,
+	"bold":       font.Bold,
+	"extrabold":  font.ExtraBold,
+	"black":      font.Black,
+}
+
+// parseWeight reads a weight, like bold, or a number from 100 to 900 like in CSS
+func parseWeight(s string) (font.Weight, error) {
+	if s == "" {
+		return font.Normal, nil
+	}
+	if w, ok := weightNames[strings.ToLower(s)]; ok {
+		return w, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 100 || n > 900 {
+		return 0, fmt.Errorf("font weight %q is not a name like light or bold, or a number from 100 to 900", s)
+	}
+	return font.Weight(n - 400), nil
+}
+
+// loadFonts reads font files. A .ttc file may hold several faces, and all of them are used.
+func loadFonts(paths []string) ([]font.FontFace, error) {
+	faces := []font.FontFace{}
+	for _, path := range paths {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ttf", ".otf", ".ttc", ".otc":
+		default:
+			return nil, fmt.Errorf("%s: only .ttf, .otf and .ttc font files can be used", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		collection, err := opentype.ParseCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s could not be read as a font: %w", path, err)
+		}
+		for _, f := range collection {
+			fmt.Printf("FONT  : %s, %s %v\n", filepath.Base(path), f.Font.Typeface, f.Font.Weight)
+		}
+		faces = append(faces, collection...)
+	}
+	return faces, nil
+}
+
+// chooseFace is the face for the text: the one asked for, or else the first font loaded.
+// Faces that aren't in the font files or the Go fonts must be installed on the system, which we tell about, in case of a typo.
+func chooseFace(faces []font.FontFace, asked string) font.Typeface {
+	if asked == "" {
+		if len(faces) > 0 {
+			return faces[0].Font.Typeface
+		}
+		return ""
+	}
+	known := append(slices.Clone(faces), gofont.Collection()...)
+	for _, name := range strings.Split(asked, ",") {
+		name = strings.TrimSpace(name)
+		found := slices.ContainsFunc(known, func(f font.FontFace) bool {
+			return strings.EqualFold(string(f.Font.Typeface), name)
+		})
+		if !found {
+			fmt.Printf("FONT  : %q is not in the font files, so it must be installed on the system\n", name)
+		}
+	}
+	return font.Typeface(asked)
+}
+
+// newTheme makes a material theme with our fonts.
+// It must be called after the window is opened, since the shaper may read the fonts of the system.
+func newTheme() *material.Theme {
+	th := material.NewTheme()
+	th.Shaper = text.NewShaper(text.WithCollection(append(slices.Clone(fontFaces), gofont.Collection()...)))
+	th.Face = textFace
+	return th
+}
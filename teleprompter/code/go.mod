@@ -1,18 +1,21 @@
-module main
+module teleprompter
 
 go 1.23.0
 
 toolchain go1.24.0
 
-require gioui.org v0.8.0
+require (
+	gioui.org v0.8.0
+	gioui.org/x v0.8.1
+	golang.org/x/net v0.39.0
+)
 
 require (
-	gioui.org/cpu v0.0.0-20220412190645-f1e9e8c3b1f7 // indirect
 	gioui.org/shader v1.0.8 // indirect
 	github.com/go-text/typesetting v0.3.0 // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
 	golang.org/x/exp/shiny v0.0.0-20250305212735-054e65f0b394 // indirect
-	golang.org/x/image v0.25.0 // indirect
+	golang.org/x/image v0.25.0
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
+	golang.org/x/text v0.24.0
 )
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"image/color"
+)
+
+// Highlighting the focus.
+// The paragraphs under the focus bar are drawn in the highlight color of the color mode,
+// and the others fade the further they are from it, so the eye finds its place even without the bar.
+// How much they fade is set with -dim or in the preferences, from 0 for not at all to 1 for all the way.
+
+// After this many paragraphs from the focus bar, the text is as faded as it gets
+const dimDistance = 4
+
+// focusRange finds the paragraphs under the focus bar, from first to last.
+// If no paragraph is under the bar, both are -1.
+func focusRange(placed []paragraphPos, barTop, barBottom int) (first, last int) {
+	first, last = -1, -1
+	for _, p := range placed {
+		if p.height <= 0 || p.top >= barBottom || p.top+p.height <= barTop {
+			continue
+		}
+		if first < 0 {
+			first = p.index
+		}
+		last = p.index
+	}
+	return first, last
+}
+
+// paragraphColor is the color to draw a paragraph in, given the paragraphs under the focus bar.
+// Before we know where the focus bar is, all the text is drawn as usual.
+func paragraphColor(colors colorMode, index, first, last int, dim float32) color.NRGBA {
+	if first < 0 {
+		return colors.foreground
+	}
+	if index >= first && index <= last {
+		return colors.highlight
+	}
+	distance := first - index
+	if index > last {
+		distance = index - last
+	}
+	fade := dim * float32(min(distance, dimDistance)) / dimDistance
+	fg := colors.foreground
+	fg.A = uint8(float32(fg.A) * (1 - fade))
+	return fg
+}
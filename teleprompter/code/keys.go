@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+)
+
+// Key bindings.
+// Every key does a named action, and which keys do what can be changed in the preferences file:
+//
+//	"keys": {
+//	  "start-stop": ["Space", "PageDown"],
+//	  "page-down": []
+//	}
+//
+// Holding Shift always works, and makes the action five times bigger,
+// unless the key is bound with Shift, like Shift+N, which is then a key of its own.
+//
+// After a search, next-match and previous-match go before the other keys, as long as there are matches.
+// That's how n and N (Shift+N) move between the matches, while n narrows the text at other times.
+
+// action is something a key can do
+type action string
+
+const (
+	actionStartStop       action = "start-stop"
+	actionFocusUp         action = "focus-up"
+	actionFocusDown       action = "focus-down"
+	actionScrollUp        action = "scroll-up"
+	actionScrollDown      action = "scroll-down"
+	actionPageUp          action = "page-up"
+	actionPageDown        action = "page-down"
+	actionSpeedUp         action = "speed-up"
+	actionSlowDown        action = "slow-down"
+	actionFontBigger      action = "font-bigger"
+	actionFontSmaller     action = "font-smaller"
+	actionWider           action = "wider"
+	actionNarrower        action = "narrower"
+	actionToggleColor     action = "toggle-color"
+	actionToggleMirror    action = "toggle-mirror"
+	actionToggleWPM       action = "toggle-wpm"
+	actionNextSection     action = "next-section"
+	actionPreviousSection action = "previous-section"
+	actionSectionMenu     action = "section-menu"
+	actionTimingReport    action = "timing-report"
+	actionNextScript      action = "next-script"
+	actionPreviousScript  action = "previous-script"
+	actionSearch          action = "search"
+	actionNextMatch       action = "next-match"
+	actionPreviousMatch   action = "previous-match"
+	actionEditParagraph   action = "edit-paragraph"
+	actionEditScript      action = "edit-script"
+	actionToggleMinimap   action = "toggle-minimap"
+	actionAlign           action = "align"
+	actionLineHeightUp    action = "line-height-up"
+	actionLineHeightDown  action = "line-height-down"
+	actionSpacingUp       action = "spacing-up"
+	actionSpacingDown     action = "spacing-down"
+	// Picking from the section menu, closing the menu, the search box or the editor, and saving an edit
+	actionConfirm action = "confirm"
+	actionCancel  action = "cancel"
+	actionSave    action = "save"
+)
+
+// defaultKeys are the keys for each action, unless the preferences say otherwise
+func defaultKeys() map[action][]string {
+	keys := map[action][]string{
+		actionStartStop:       {"Space"},
+		actionFocusUp:         {"U"},
+		actionFocusDown:       {"D"},
+		actionScrollUp:        {"K", "Up"},
+		actionScrollDown:      {"J", "Down"},
+		actionPageUp:          {"PageUp"},
+		actionPageDown:        {"PageDown"},
+		actionSpeedUp:         {"F"},
+		actionSlowDown:        {"S"},
+		actionFontBigger:      {"+"},
+		actionFontSmaller:     {"-"},
+		actionWider:           {"W"},
+		actionNarrower:        {"N"},
+		actionToggleColor:     {"C"},
+		actionToggleMirror:    {"M"},
+		actionToggleWPM:       {"P"},
+		actionNextSection:     {"]"},
+		actionPreviousSection: {"["},
+		actionSectionMenu:     {"L"},
+		actionTimingReport:    {"T"},
+		actionNextScript:      {"."},
+		actionPreviousScript:  {","},
+		actionSearch:          {"/"},
+		actionNextMatch:       {"N", "Shortcut+N"},
+		actionPreviousMatch:   {"Shift+N", "Shortcut+P"},
+		actionEditParagraph:   {"E"},
+		actionEditScript:      {"Shortcut+E"},
+		actionToggleMinimap:   {"B"},
+		actionAlign:           {"A"},
+		actionLineHeightUp:    {"Alt+Up"},
+		actionLineHeightDown:  {"Alt+Down"},
+		actionSpacingUp:       {"Alt+Right"},
+		actionSpacingDown:     {"Alt+Left"},
+		actionConfirm:         {"Return", "Enter"},
+		actionCancel:          {"Escape"},
+		actionSave:            {"Shortcut+S"},
+	}
+	// 1 to 9 go to the bookmarks, and Ctrl+1 to Ctrl+9 set them
+	for n := 1; n <= 9; n++ {
+		goTo, set := bookmarkActions(n)
+		keys[goTo] = []string{strconv.Itoa(n)}
+		keys[set] = []string{"Shortcut+" + strconv.Itoa(n)}
+	}
+	return keys
+}
+
+// Friendly names for the keys that don't print as themselves
+var keyNames = map[string]key.Name{
+	"space":     key.NameSpace,
+	"up":        key.NameUpArrow,
+	"down":      key.NameDownArrow,
+	"left":      key.NameLeftArrow,
+	"right":     key.NameRightArrow,
+	"pageup":    key.NamePageUp,
+	"pagedown":  key.NamePageDown,
+	"home":      key.NameHome,
+	"end":       key.NameEnd,
+	"return":    key.NameReturn,
+	"enter":     key.NameEnter,
+	"escape":    key.NameEscape,
+	"esc":       key.NameEscape,
+	"tab":       key.NameTab,
+	"backspace": key.NameDeleteBackward,
+	"delete":    key.NameDeleteForward,
+}
+
+// Modifiers a binding can require, written like Ctrl+1
+var modifierNames = map[string]key.Modifiers{
+	"ctrl":     key.ModCtrl,
+	"alt":      key.ModAlt,
+	"cmd":      key.ModCommand,
+	"shortcut": key.ModShortcut,
+	"shift":    key.ModShift,
+}
+
+// The actions that only work while a search has matches, and then go before the others
+var searchActions = []action{actionNextMatch, actionPreviousMatch}
+
+// keyBinding is a key, together with the modifiers that must be held down.
+// Shift is only part of it when written out, since Shift otherwise sets the step size.
+type keyBinding struct {
+	name key.Name
+	mods key.Modifiers
+}
+
+func (k keyBinding) String() string {
+	if k.mods == 0 {
+		return string(k.name)
+	}
+	return k.mods.String() + "+" + string(k.name)
+}
+
+// parseKey reads a key as written in the preferences, like J, PageDown or Ctrl+1
+func parseKey(s string) (keyBinding, error) {
+	parts := strings.Split(s, "+")
+	// A plain + is the plus key, not a modifier
+	if strings.HasSuffix(s, "++") || s == "+" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	var k keyBinding
+	for _, mod := range parts[:len(parts)-1] {
+		m, ok := modifierNames[strings.ToLower(mod)]
+		if !ok {
+			return k, fmt.Errorf("unknown modifier %q in key %q", mod, s)
+		}
+		k.mods |= m
+	}
+	name := parts[len(parts)-1]
+	if name == "" {
+		return k, fmt.Errorf("missing key in %q", s)
+	}
+	if n, ok := keyNames[strings.ToLower(name)]; ok {
+		k.name = n
+	} else {
+		k.name = key.Name(strings.ToUpper(name))
+	}
+	return k, nil
+}
+
+// bindings tell which action each key does, and which keys go first while a search has matches
+type bindings struct {
+	byKey          map[keyBinding]action
+	whileSearching map[keyBinding]action
+}
+
+// newBindings combines the default keys with those from the preferences.
+// An action in the preferences replaces all the default keys for that action.
+// Unknown actions, unknown keys and keys bound to two actions are all errors.
+// The search actions may take keys of other actions, since they only work after a search.
+func newBindings(custom map[string][]string) (*bindings, error) {
+	keys := defaultKeys()
+	for name, list := range custom {
+		a := action(name)
+		if _, ok := keys[a]; !ok {
+			return nil, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+		keys[a] = list
+	}
+
+	// Go through the actions in order, so the errors come out the same every time
+	actions := make([]action, 0, len(keys))
+	for a := range keys {
+		actions = append(actions, a)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+
+	b := &bindings{byKey: map[keyBinding]action{}, whileSearching: map[keyBinding]action{}}
+	problems := []string{}
+	for _, a := range actions {
+		for _, s := range keys[a] {
+			k, err := parseKey(s)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			layer := b.byKey
+			if slices.Contains(searchActions, a) {
+				layer = b.whileSearching
+			}
+			if other, taken := layer[k]; taken && other != a {
+				problems = append(problems, fmt.Sprintf("%v is bound to both %s and %s", k, other, a))
+				continue
+			}
+			layer[k] = a
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("key bindings:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return b, nil
+}
+
+// filters lists a key.Filter for every bound key, with Shift allowed for bigger steps.
+// Given some actions, only their keys are listed.
+func (b *bindings) filters(only ...action) []event.Filter {
+	filters := make([]event.Filter, 0, len(b.byKey)+len(b.whileSearching))
+	for _, layer := range []map[keyBinding]action{b.byKey, b.whileSearching} {
+		for k, a := range layer {
+			if len(only) > 0 && !slices.Contains(only, a) {
+				continue
+			}
+			f := key.Filter{Name: k.name, Required: k.mods, Optional: key.ModShift}
+			if k.mods.Contain(key.ModShift) {
+				f.Optional = 0
+			}
+			filters = append(filters, f)
+		}
+	}
+	return filters
+}
+
+// lookup finds the action for a key event. While a search has matches, its keys go first.
+// A key bound with Shift goes before the same key without.
+func (b *bindings) lookup(e key.Event, searching bool) (action, bool) {
+	layers := []map[keyBinding]action{b.byKey}
+	if searching {
+		layers = []map[keyBinding]action{b.whileSearching, b.byKey}
+	}
+	for _, layer := range layers {
+		for _, k := range []keyBinding{{name: e.Name, mods: e.Modifiers}, {name: e.Name, mods: e.Modifiers &^ key.ModShift}} {
+			if a, ok := layer[k]; ok {
+				return a, true
+			}
+		}
+	}
+	return "", false
+}
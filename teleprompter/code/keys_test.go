@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want keyBinding
+		err  bool
+	}{
+		{"J", keyBinding{name: "J"}, false},
+		{"j", keyBinding{name: "J"}, false},
+		{"PageDown", keyBinding{name: key.NamePageDown}, false},
+		{"Ctrl+1", keyBinding{name: "1", mods: key.ModCtrl}, false},
+		{"Shortcut+E", keyBinding{name: "E", mods: key.ModShortcut}, false},
+		{"+", keyBinding{name: "+"}, false},
+		{"Alt++", keyBinding{name: "+", mods: key.ModAlt}, false},
+		{"Shift+N", keyBinding{name: "N", mods: key.ModShift}, false},
+		{"Hyper+J", keyBinding{}, true},
+		{"Ctrl+", keyBinding{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseKey(tt.in)
+		if (err != nil) != tt.err || (err == nil && got != tt.want) {
+			t.Errorf("parseKey(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewBindings(t *testing.T) {
+	tests := []struct {
+		name   string
+		custom map[string][]string
+		err    string
+	}{
+		{"the defaults", nil, ""},
+		{"a key of a bookmark", map[string][]string{"start-stop": {"1"}}, "1 is bound to both bookmark-1 and start-stop"},
+		{"a key for setting a bookmark", map[string][]string{"search": {"Shortcut+2"}}, "is bound to both search and set-bookmark-2"},
+		{"a bookmark moved out of the way", map[string][]string{"start-stop": {"1"}, "bookmark-1": {"Alt+1"}}, ""},
+		{"the key that closes things", map[string][]string{"start-stop": {"Escape"}}, "is bound to both cancel and start-stop"},
+		{"the key that saves an edit", map[string][]string{"speed-up": {"Ctrl+S"}}, "is bound to both save and speed-up"},
+		{"Enter", map[string][]string{"page-down": {"Enter"}}, "is bound to both confirm and page-down"},
+		{"two keys for moving between matches", map[string][]string{"previous-match": {"N"}}, "N is bound to both next-match and previous-match"},
+		{"a search key may be a key of another action", map[string][]string{"wider": {"Shortcut+N"}}, ""},
+		{"an unknown action", map[string][]string{"bookmark-10": {"0"}}, `unknown action "bookmark-10"`},
+	}
+	for _, tt := range tests {
+		_, err := newBindings(tt.custom)
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error %v, want one about %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestBookmarkAction(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		goTo, set := bookmarkActions(n)
+		if got, isSet, ok := goTo.bookmark(); !ok || isSet || got != n {
+			t.Errorf("%s: bookmark %d, set %v, ok %v", goTo, got, isSet, ok)
+		}
+		if got, isSet, ok := set.bookmark(); !ok || !isSet || got != n {
+			t.Errorf("%s: bookmark %d, set %v, ok %v", set, got, isSet, ok)
+		}
+	}
+	for _, a := range []action{actionStartStop, "bookmark-0", "bookmark-x", "set-bookmark-"} {
+		if _, _, ok := a.bookmark(); ok {
+			t.Errorf("%s is taken for a bookmark", a)
+		}
+	}
+}
+
+// n and N move between the matches of a search, and otherwise narrow the text
+func TestLookupWhileSearching(t *testing.T) {
+	b, err := newBindings(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      key.Name
+		mods      key.Modifiers
+		searching bool
+		want      action
+	}{
+		{"N", 0, false, actionNarrower},
+		{"N", key.ModShift, false, actionNarrower},
+		{"N", 0, true, actionNextMatch},
+		{"N", key.ModShift, true, actionPreviousMatch},
+		{"N", key.ModShortcut, true, actionNextMatch},
+		{"N", key.ModShortcut, false, ""},
+		{"P", key.ModShortcut, true, actionPreviousMatch},
+		{"W", key.ModShift, true, actionWider},
+	}
+	for _, tt := range tests {
+		got, _ := b.lookup(key.Event{Name: tt.name, Modifiers: tt.mods}, tt.searching)
+		if got != tt.want {
+			t.Errorf("%v %v, searching %v: %s, want %s", tt.mods, tt.name, tt.searching, got, tt.want)
+		}
+	}
+}
@@ -6,78 +6,330 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
-	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
-	"gioui.org/text"
 	"gioui.org/unit"
-	"gioui.org/widget/material"
 )
 
 // Command line input variables
-var filename *string
+var files fileList
+var startMirror mirrorMode
+var startWPM float32
+var slotDuration time.Duration
+var showNotes bool
+var sectionPattern *regexp.Regexp
+var listener net.Listener
+var reportPath string
+
+// Preferences, from file and command line, and where to save them. An empty prefsPath means don't save.
+var prefs preferences
+var prefsPath string
+
+// Which key does what, from the defaults and the preferences
+var keyBindings *bindings
+
+// The color themes, built in and from the preferences
+var themes []colorMode
 
 // Define context and dimension types, just for shorthand comfort
 type C = layout.Context
 type D = layout.Dimensions
 
-// A []string to hold the speech as a list of paragraphs
-var paragraphList []string
-
-// Colors
+// Colors, see themes.go for more
 type colorMode struct {
+	name       string
 	background color.NRGBA
 	foreground color.NRGBA
 	focusbar   color.NRGBA
+	// The text under the focus bar
+	highlight color.NRGBA
+	// Notes for the director
+	note color.NRGBA
+}
+
+var colorDark = colorMode{
+	name:       "dark",
+	background: color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	foreground: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	focusbar:   color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x33},
+	highlight:  color.NRGBA{R: 0xff, G: 0xf0, B: 0x90, A: 0xff},
+	note:       color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x55},
+}
+
+var colorLight = colorMode{
+	name:       "light",
+	background: color.NRGBA{R: 0xff, G: 0xfe, B: 0xe0, A: 0xff},
+	foreground: color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	focusbar:   color.NRGBA{R: 0xff, A: 0x66},
+	highlight:  color.NRGBA{R: 0x00, G: 0x20, B: 0x80, A: 0xff},
+	note:       color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0x55},
 }
 
 func main() {
 	// Step 1 - Read input from command line
-	filename = flag.String("file", "speech.txt", "Which .txt file shall I present?")
+	flag.Var(&files, "file", "Which .txt file shall I present? Use .md for headings, *emphasis* and // notes, or .srt and .vtt for timed captions.\nRepeat it, or list files after the flags, for a show in several segments. A .playlist file lists the files, one per line. - reads from stdin.\nDefault is speech.txt")
+	mirror := flag.String("mirror", "none", "Mirror the text for a beam-splitter glass: none, h, v or hv")
+	wpm := flag.Float64("wpm", 0, "Scroll at this many words per minute. 0 means a fixed speed instead")
+	flag.DurationVar(&slotDuration, "duration", 0, "Pace the speech to finish in this time, like 4m30s. 0 means no time limit")
+	flag.BoolVar(&showNotes, "notes", false, "Show // notes for the director, dimmed. They are hidden by default")
+	sections := flag.String("sections", "", "Lines matching this regular expression start a section, like '^(Q&A|Closing)'. Default is the # headings")
+	listen := flag.String("listen", "", "Serve a remote control on this address, like :8080. Off by default")
+	operator := flag.Bool("operator", false, "Open a second window for the operator, with the script, position, speed and controls")
+	record := flag.String("record", "", "Record how the text scrolls to this file, .csv or .jsonl, to replay it later")
+	flag.StringVar(&reportPath, "report", "", "Save a timing report, with the seconds spent on each paragraph, as this name .csv and .txt when closing. With several scripts, each gets a report of its own")
+	replayFile := flag.String("replay", "", "Replay a recorded rehearsal from this file. The keyboard and mouse are ignored")
+	defaults := defaultPreferences()
+	fontSize := flag.Float64("fontsize", float64(defaults.FontSize), "Font size")
+	textWidth := flag.Float64("width", float64(defaults.TextWidth), "Width of the text")
+	focusBarY := flag.Float64("focusbar", float64(defaults.FocusBarY), "Position of the focus bar, from the top")
+	theme := flag.String("theme", defaults.ColorMode, "Color theme to start with: dark, light, contrast, night, or a theme from the preferences file")
+	colorName := flag.String("color", defaults.ColorMode, "Same as -theme")
+	speed := flag.Float64("speed", float64(defaults.Speed), "Autoscroll speed, in Dp per second")
+	dim := flag.Float64("dim", float64(defaults.Dim), "How much the text fades away from the focus bar, from 0 for not at all to 1 for all the way")
+	align := flag.String("align", defaults.Alignment, "Text alignment: start, middle or end")
+	lineHeight := flag.Float64("lineheight", float64(defaults.LineHeight), "Line height, as a multiple of the usual. 1.5 is looser")
+	spacing := flag.Float64("spacing", float64(defaults.ParagraphSpacing), "Space after each paragraph, in font sizes")
+	var fonts fileList
+	flag.Var(&fonts, "font", "A font file for the text, .ttf, .otf or .ttc. Repeat it for fonts to fall back on. Default is the Go fonts")
+	face := flag.String("face", defaults.FontFace, "Which font face to use, like 'Noto Serif'. Several, separated by commas, are tried in order. Default is the first -font")
+	weight := flag.String("weight", defaults.FontWeight, "Font weight, like light, normal or bold, or a number from 100 to 900")
+	flag.StringVar(&prefsPath, "prefs", "", "Where to keep the preferences. Default is teleprompter/preferences.json in the user config directory")
 	flag.Parse()
+	startWPM = float32(*wpm)
+
+	var err error
+	startMirror, err = parseMirror(*mirror)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *sections != "" {
+		sectionPattern, err = regexp.Compile(*sections)
+		if err != nil {
+			log.Fatal("Error in -sections:\n  ", err)
+		}
+	}
+	if *listen != "" {
+		listener, err = net.Listen("tcp", *listen)
+		if err != nil {
+			log.Fatal("Error when starting the remote control:\n  ", err)
+		}
+	}
+
+	// Step 2 - Read the preferences from last time, and let the command line override them
+	if prefsPath == "" {
+		prefsPath, err = preferencesPath()
+		if err != nil {
+			fmt.Printf("PREFS : preferences will not be saved: %v\n", err)
+		}
+	}
+	prefs = defaultPreferences()
+	if prefsPath != "" {
+		prefs, err = loadPreferences(prefsPath)
+		if err != nil {
+			log.Fatal("Error when reading preferences:\n  ", err)
+		}
+	}
+	themeGiven, fontsGiven, faceGiven := false, false, false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "fontsize":
+			prefs.FontSize = float32(*fontSize)
+		case "width":
+			prefs.TextWidth = float32(*textWidth)
+		case "focusbar":
+			prefs.FocusBarY = float32(*focusBarY)
+		case "color":
+			prefs.ColorMode, themeGiven = *colorName, true
+		case "theme":
+			prefs.ColorMode, themeGiven = *theme, true
+		case "speed":
+			prefs.Speed = float32(*speed)
+		case "dim":
+			prefs.Dim = float32(*dim)
+		case "align":
+			prefs.Alignment = *align
+		case "lineheight":
+			prefs.LineHeight = float32(*lineHeight)
+		case "spacing":
+			prefs.ParagraphSpacing = float32(*spacing)
+		case "font":
+			// Saved with the full path, since the prompter may be started from another folder next time
+			prefs.Fonts, fontsGiven = make([]string, len(fonts)), true
+			for i, path := range fonts {
+				if prefs.Fonts[i], err = filepath.Abs(path); err != nil {
+					log.Fatal("Error when finding font:\n  ", err)
+				}
+			}
+		case "face":
+			prefs.FontFace, faceGiven = *face, true
+		case "weight":
+			prefs.FontWeight = *weight
+		}
+	})
+	if err := prefs.check(); err != nil {
+		log.Fatal(err)
+	}
+	keyBindings, err = newBindings(prefs.Keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	themes, err = buildThemes(prefs.Themes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// A theme from last time may since have been taken out of the preferences
+	if _, ok := findTheme(themes, prefs.ColorMode); !ok {
+		if themeGiven {
+			log.Fatalf("There is no color theme called %q. Choose from %s", prefs.ColorMode, themeNames(themes))
+		}
+		fmt.Printf("PREFS : there is no color theme called %q, so the default is used\n", prefs.ColorMode)
+		prefs.ColorMode = defaultPreferences().ColorMode
+	}
+	fontFaces, err = loadFonts(prefs.Fonts)
+	// Fonts from last time may since have been moved or deleted
+	if err != nil && !fontsGiven {
+		fmt.Printf("PREFS : %v, so the default font is used\n", err)
+		prefs.Fonts = nil
+		if !faceGiven {
+			prefs.FontFace = defaultPreferences().FontFace
+		}
+		fontFaces, err = nil, nil
+	}
+	if err != nil {
+		log.Fatal("Error when loading fonts:\n  ", err)
+	}
+	textFace = chooseFace(fontFaces, prefs.FontFace)
+	textWeight, _ = parseWeight(prefs.FontWeight)
+
+	// Step 3 - Read from file
+	files = append(files, flag.Args()...)
+	if len(files) == 0 {
+		files = fileList{"speech.txt"}
+	}
+	files, err = expandPlaylists(files)
+	if err != nil {
+		log.Fatal("Error when reading playlist:\n  ", err)
+	}
+	if len(files) == 0 {
+		log.Fatal("playlist lists no scripts")
+	}
+	scripts := make([]*script, len(files))
+	for i, filename := range files {
+		scripts[i] = &script{filename: filename, paragraphs: readText(filename)}
+		// Bookmarks that can't be read are left out, but the show goes on
+		if scripts[i].bookmarks, err = loadBookmarks(filename); err != nil {
+			fmt.Printf("MARK  : %v\n", err)
+		}
+	}
+	p := newPrompter(scripts)
+
+	// Record or replay a rehearsal, if asked for
+	if *replayFile != "" {
+		t, err := loadTimeline(*replayFile)
+		if err != nil {
+			log.Fatal("Error when reading the timeline to replay:\n  ", err)
+		}
+		p.replay = &replay{timeline: t}
+	}
+	if *record != "" {
+		p.recorder, err = newRecorder(*record)
+		if err != nil {
+			log.Fatal("Error when starting to record:\n  ", err)
+		}
+	}
+
+	// Step 4 - Start the GUI
+	// create new window for the talent, and one for the operator if asked for
+	w := new(app.Window)
+	w.Option(app.Title("Teleprompter"))
+	w.Option(app.Size(unit.Dp(prefs.WindowWidth), unit.Dp(prefs.WindowHeight)))
+	p.windows = append(p.windows, w)
+	var ow *app.Window
+	if *operator {
+		ow = new(app.Window)
+		ow.Option(app.Title("Teleprompter - Operator"))
+		ow.Option(app.Size(unit.Dp(500), unit.Dp(700)))
+		p.windows = append(p.windows, ow)
+	}
+
+	// reload the speech when a file changes. Stdin can't change.
+	scriptReloads = make(chan scriptReload, len(scripts))
+	for i, s := range scripts {
+		if s.filename != "-" {
+			go watchText(i, s.filename, w.Invalidate)
+		}
+	}
 
-	// Step 2 - Read from file
-	paragraphList = readText(filename)
+	// start the remote control, if asked for
+	var rc *remote
+	if listener != nil {
+		rc = newRemote(w.Invalidate)
+		go func() {
+			log.Fatal(http.Serve(listener, rc.handler()))
+		}()
+	}
 
-	// Step 3 - Start the GUI
 	go func() {
-		// create new window
-		w := new(app.Window)
-		w.Option(app.Title("Teleprompter"))
-		w.Option(app.Size(unit.Dp(650), unit.Dp(600)))
 		// draw on screen
-		if err := draw(w); err != nil {
+		if err := draw(w, p, rc); err != nil {
 			log.Fatal(err)
 		}
 		os.Exit(0)
 	}()
+	if ow != nil {
+		go func() {
+			// the operator window can be closed without stopping the show
+			if err := drawOperator(ow, p); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 
 	app.Main()
 }
 
-func readText(filename *string) []string {
-	f, err := os.ReadFile(*filename)
-	text := []string{}
+func readText(filename string) []paragraph {
+	text, err := loadText(filename)
 	if err != nil {
 		log.Fatal("Error when reading file:\n  ", err)
 	}
-	if err == nil {
-		// Convert whole text into a slice of strings.
-		text = strings.Split(string(f), "\n")
-		// Add extra empty lines a the end. Simple trick to ensure
-		// the last line of the speech scrolls out of the screen
-		for i := 1; i <= 10; i++ {
-			text = append(text, "")
+	return text
+}
+
+// loadText reads the speech from a file. Unlike readText it never stops the program,
+// which is what we need when the file is reloaded while we're on air.
+func loadText(filename string) ([]paragraph, error) {
+	f, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	// Subtitles have a paragraph per caption, and the same empty lines at the end
+	if isSubtitles(filename) {
+		paragraphs, err := loadSubtitles(string(f))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
 		}
+		return append(paragraphs, make([]paragraph, 10)...), nil
+	}
+	// Convert whole text into a slice of strings.
+	text := strings.Split(string(f), "\n")
+	// Add extra empty lines a the end. Simple trick to ensure
+	// the last line of the speech scrolls out of the screen
+	for i := 1; i <= 10; i++ {
+		text = append(text, "")
 	}
 
 	// Alternative to reading from file, we can generate paragraphs programatically
@@ -85,29 +337,28 @@ func readText(filename *string) []string {
 	//for i := 1; i <= 2500; i++ {
 	//	text = append(text, fmt.Sprintf("Eloquent speech, interesting phrase %d", i))
 	//}
-	return text
-}
-
-// The main draw function
-func draw(w *app.Window) error {
-	// y-position for text
-	var scrollY unit.Dp = 0
 
-	// y-position for red focusBar
-	var focusBarY unit.Dp = 170
+	// Finally, read any markup, if this is a markup file
+	return parseScript(text, isMarkup(filename)), nil
+}
 
-	// width of text area
-	var textWidth unit.Dp = 550
+// The main draw function, for the talent's window.
+// p is the prompter, shared with the operator window.
+// rc is the remote control, which is nil when not in use.
+func draw(w *app.Window, p *prompter, rc *remote) error {
+	// The window size, to be saved with the preferences
+	var windowWidth, windowHeight unit.Dp = unit.Dp(prefs.WindowWidth), unit.Dp(prefs.WindowHeight)
 
-	// fontSize
-	var fontSize unit.Sp = 35
+	// The height of each paragraph laid out
+	paragraphHeights := map[int]int{}
 
-	// Are we auto scrolling?
-	var autoscroll bool = false
-	var autospeed unit.Dp = 1
+	// A menu to pick sections from, a box to search in and an editor
+	var ui overlays
+	// The whole script in a bar along the edge
+	var mini minimap
 
-	// th defines the material design style
-	th := material.NewTheme()
+	// th defines the material design style, with our fonts
+	th := newTheme()
 
 	// ops are the operations from the UI
 	var ops op.Ops
@@ -115,22 +366,6 @@ func draw(w *app.Window) error {
 	// Define a tag for input routing
 	var tag = "My Input Routing Tag - which could be this silly string, or an int/float/address, or anything else"
 
-	// Colors
-	colorDark := colorMode{
-		background: color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
-		foreground: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
-		focusbar:   color.NRGBA{R: 0xff, G: 0x00, B: 0x00, A: 0x33},
-	}
-
-	colorLight := colorMode{
-		background: color.NRGBA{R: 0xff, G: 0xfe, B: 0xe0, A: 0xff},
-		foreground: color.NRGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
-		focusbar:   color.NRGBA{R: 0xff, A: 0x66},
-	}
-
-	// Define a color to start with. We like dark
-	myColor := colorDark
-
 	for {
 
 		// listen for events in the window
@@ -139,9 +374,28 @@ func draw(w *app.Window) error {
 		// Should we draw a new frame?
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, winE)
+			windowWidth, windowHeight = gtx.Metric.PxToDp(winE.Size.X), gtx.Metric.PxToDp(winE.Size.Y)
+
+			// The prompter is shared with the operator window, so we hold on to it while drawing
+			p.mu.Lock()
+			p.metric = gtx.Metric
 
 			// ---------- Handle input ----------
 			// Time to deal with inputs since last frame.
+			// If anything changes, the operator window must be redrawn too.
+			changed := false
+
+			// A new version of the speech?
+			for len(scriptReloads) > 0 {
+				p.reload(<-scriptReloads)
+				changed = true
+			}
+
+			// Commands from the remote control?
+			for _, cmd := range rc.pending() {
+				p.command(cmd)
+				changed = true
+			}
 
 			// Scrolled a mouse wheel?
 			for {
@@ -156,10 +410,8 @@ func draw(w *app.Window) error {
 					break
 				}
 				fmt.Printf("SCROLL: %+v\n", ev)
-				scrollY = scrollY + unit.Dp(ev.(pointer.Event).Scroll.Y*float32(fontSize))
-				if scrollY < 0 {
-					scrollY = 0
-				}
+				p.scroll(unit.Dp(ev.(pointer.Event).Scroll.Y * float32(p.fontSize)))
+				changed = true
 			}
 
 			// Pressed a mouse button?
@@ -174,158 +426,118 @@ func draw(w *app.Window) error {
 					break
 				}
 				fmt.Printf("PRESS : %+v\n", ev)
-				// Start / stop
-				autoscroll = !autoscroll
-			}
-
-			// Pressed a key?
-			for {
-				ev, ok := gtx.Event(
-					key.Filter{Name: key.NameSpace},
-					key.Filter{Optional: key.ModShift, Name: "U"},
-					key.Filter{Optional: key.ModShift, Name: "D"},
-					key.Filter{Optional: key.ModShift, Name: "J"},
-					key.Filter{Optional: key.ModShift, Name: "K"},
-					key.Filter{Optional: key.ModShift, Name: key.NameUpArrow},
-					key.Filter{Optional: key.ModShift, Name: key.NameDownArrow},
-					key.Filter{Optional: key.ModShift, Name: key.NamePageUp},
-					key.Filter{Optional: key.ModShift, Name: key.NamePageDown},
-					key.Filter{Optional: key.ModShift, Name: "F"},
-					key.Filter{Optional: key.ModShift, Name: "S"},
-					key.Filter{Optional: key.ModShift, Name: "+"},
-					key.Filter{Optional: key.ModShift, Name: "-"},
-					key.Filter{Optional: key.ModShift, Name: "W"},
-					key.Filter{Optional: key.ModShift, Name: "N"},
-					key.Filter{Optional: key.ModShift, Name: "C"},
-				)
-				if !ok {
-					break
+				// Clicking outside the section menu closes it
+				if ui.menu.open {
+					ui.menu.open = false
+					continue
 				}
-				fmt.Printf("KEY   : %+v\n", ev)
-				if ev.(key.Event).State == key.Press {
-					name := ev.(key.Event).Name
-					mod := ev.(key.Event).Modifiers
-
-					// Set stepsize
-					var stepSize unit.Dp = 1
-					if mod == key.ModShift {
-						stepSize = 5
-					}
-
-					// Start / stop
-					if name == key.NameSpace {
-						autoscroll = !autoscroll
-						if autoscroll && autospeed <= 0 {
-							autospeed = stepSize
-						}
-					}
-
-					// Move the focusBar Up
-					if name == "U" {
-						focusBarY = focusBarY - stepSize
-					}
-
-					// Move the focusBar Down
-					if name == "D" {
-						focusBarY = focusBarY + stepSize
-					}
-
-					// Scroll up
-					if name == "K" || name == key.NameUpArrow {
-						scrollY = scrollY - stepSize*4
-					}
-					if name == key.NamePageUp {
-						scrollY = scrollY - stepSize*100
-					}
-					if scrollY < 0 {
-						scrollY = 0
-					}
-
-					// Scroll down
-					if name == "J" || name == key.NameDownArrow || name == key.NamePageDown {
-						scrollY = scrollY + stepSize*4
-					}
-					if name == key.NamePageDown {
-						scrollY = scrollY + stepSize*100
-					}
-
-					// Faster scrollspeed
-					if name == "F" {
-						autoscroll = true
-						autospeed += stepSize
-					}
-
-					// Slower scrollspeed
-					if name == "S" {
-						if autospeed > 0 {
-							autospeed -= stepSize
-						}
-						if autospeed <= 0 {
-							autospeed = 0
-							autoscroll = false
-						}
-					}
-
-					// To increase the fontsize
-					if name == "+" {
-						fontSize = fontSize + unit.Sp(stepSize)
-					}
-
-					// To decrease the fontsize
-					if name == "-" {
-						fontSize = fontSize - unit.Sp(stepSize)
-					}
+				// Start / stop, just like Space
+				p.do(actionStartStop, 1)
+				changed = true
+			}
 
-					// Widen text to be displayed
-					if name == "W" {
-						textWidth = textWidth + stepSize*10
-					}
-					// Narrow text to be displayed
-					if name == "N" {
-						textWidth = textWidth - stepSize*10
-					}
+			// Pressed a key, or typed in the search box or the editor?
+			if p.handleKeyboard(gtx, &ui) {
+				changed = true
+			}
 
-					// Swhich Colormode
-					if name == "C" {
-						if myColor == colorDark {
-							myColor = colorLight
-						} else {
-							myColor = colorDark
-						}
-					}
-				}
+			// Clicked or dragged in the minimap?
+			if mini.update(gtx, p) {
+				changed = true
 			}
 
 			// ---------- LAYOUT ----------
 			// First we layout the user interface.
 			// Let's start with a background color
-			paint.Fill(&ops, myColor.background)
+			paint.Fill(&ops, p.color.background)
 
 			// ---------- THE SCROLLING TEXT ----------
 			// First, check if we should autoscroll
-			// That's done by increasing the value of scrollY
-			if autoscroll {
-				if autospeed < 0 {
-					autospeed = 0
+			// That's done by scrolling on from where we are,
+			// by the speed multiplied with the time since the last frame.
+			barTop, barBottom := p.focusBarSpan()
+			// Cues in the script that reached the focus bar may pause, stop or change the speed
+			if p.replay == nil {
+				p.runCues(gtx.Now)
+			}
+			// Count the time spent on the paragraph under the focus bar
+			p.timing.track(gtx.Now, p.focus(), p.autoscroll)
+			if p.replay != nil {
+				// Replaying a rehearsal. The timeline decides where the text is, and nothing else moves it.
+				p.jumpTo = -1
+				if p.replay.apply(gtx.Now, p) {
+					gtx.Execute(op.InvalidateCmd{})
+				}
+			} else if p.autoscroll {
+				if p.started.IsZero() {
+					p.started = gtx.Now
+				}
+				// With a time slot, plan the pace needed to finish on time.
+				// Changes to the pace made with F and S are kept on top of the plan.
+				if p.wpmMode && p.schedule != nil {
+					p.schedule.start(gtx.Now)
+					index, fraction := focusParagraph(p.onScreen, barTop, barBottom)
+					remaining := remainingWords(p.paragraphList, p.paragraphWords, index, fraction)
+					p.targetWPM = p.schedule.pace(gtx.Now, index, remaining, p.targetWPM)
+				}
+				// With subtitles, the speed brings each caption to the focus bar in time.
+				// In words per minute mode, the speed follows the words passing the focus bar.
+				if p.hasCaptions() {
+					if pxPerSecond, ok := p.captionSpeed(gtx.Now, barTop); ok {
+						p.autospeed = unit.Dp(pxPerSecond / gtx.Metric.PxPerDp)
+					}
+				} else if p.wpmMode {
+					pxPerSecond := wpmSpeed(p.targetWPM, p.onScreen, p.paragraphWords, barTop, barBottom)
+					p.autospeed = unit.Dp(pxPerSecond / gtx.Metric.PxPerDp)
+				}
+				if p.autospeed < 0 {
+					p.autospeed = 0
+				}
+				// A slow cue halves the speed for a line
+				speed := p.autospeed
+				if p.slowAt >= 0 {
+					speed = speed / 2
+				}
+				if gtx.Now.Before(p.holdUntil) {
+					// Holding for a pause cue. Check back every second, to count down.
+					next := gtx.Now.Add(time.Second)
+					if p.holdUntil.Before(next) {
+						next = p.holdUntil
+					}
+					gtx.Execute(op.InvalidateCmd{At: next})
+				} else {
+					p.scrollBy += scrollDistance(speed, p.lastFrame, gtx.Now)
+					// Ask for a new frame as soon as the display is ready for it
+					gtx.Execute(op.InvalidateCmd{})
+				}
+				p.lastFrame = gtx.Now
+			} else {
+				// Forget the last frame, so a pause isn't counted as scrolling time
+				p.lastFrame = time.Time{}
+				// The subtitle clock follows the text when it's moved by hand
+				if p.hasCaptions() {
+					p.seekCaptions(barTop)
+				}
+				// The clock of a time slot keeps running though, so keep showing it
+				if p.schedule != nil && !p.schedule.started.IsZero() {
+					gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Second)})
 				}
-				scrollY = scrollY + autospeed
-				// Invalidate 50 times per second
-				inv := op.InvalidateCmd{At: gtx.Now.Add(time.Second / 50)}
-				gtx.Execute(inv)
 			}
-			// Then we use scrollY to control the distance from the top of the screen to the first element.
 			// We visualize the text using a list where each paragraph is a separate item.
+			// Where the list starts is worked out from the position when the paragraphs are laid out.
 			var vizList = layout.List{
 				Axis: layout.Vertical,
-				Position: layout.Position{
-					Offset: int(scrollY),
-				},
 			}
 
+			// ---------- MIRROR ----------
+			// Everything from here until the focus bar is drawn through the mirror.
+			// With no mirroring the transform is the identity and does nothing.
+			mirrorStack := op.Affine(p.mirror.transform(gtx.Constraints.Max)).Push(&ops)
+
 			// ---------- MARGINS ----------
 			// Margins
 			var marginWidth unit.Dp
-			marginWidth = (unit.Dp(gtx.Constraints.Max.X) - textWidth) / 3
+			marginWidth = (unit.Dp(gtx.Constraints.Max.X) - p.textWidth) / 3
 			margins := layout.Inset{
 				Left:   marginWidth,
 				Right:  marginWidth,
@@ -334,39 +546,171 @@ func draw(w *app.Window) error {
 			}
 
 			// ---------- LIST WITHIN MARGINS ----------
+			// The paragraphs under the focus bar last frame are highlighted, and the rest fade away
+			focusFirst, focusLast := focusRange(p.onScreen, barTop, barBottom)
+
+			// Each paragraph is drawn by this function
+			drawParagraph := func(gtx C, index int) D {
+				fg := paragraphColor(p.color, index, focusFirst, focusLast, p.dim)
+				// Paragraphs found by a search are marked
+				if mark, ok := p.search.highlight(index); ok {
+					return layout.Background{}.Layout(gtx,
+						func(gtx C) D {
+							paint.FillShape(gtx.Ops, mark, clip.Rect{Max: gtx.Constraints.Min}.Op())
+							return D{Size: gtx.Constraints.Min}
+						},
+						func(gtx C) D {
+							return layoutParagraph(gtx, th, p.paragraphList[index], p.fontSize, fg, p.color.note, p.style, showNotes)
+						},
+					)
+				}
+				return layoutParagraph(gtx, th, p.paragraphList[index], p.fontSize, fg, p.color.note, p.style, showNotes)
+			}
+
+			// Measure the paragraphs from scratch every frame, since fonts and widths change
+			clear(paragraphHeights)
 			// 1) First the margins ...
 			margins.Layout(gtx,
 				func(gtx C) D {
+					// While editing, the editor takes the place of the text
+					if ui.edit.open {
+						return D{Size: gtx.Constraints.Max}
+					}
+					// Paragraphs are measured at the width of the margins, only when needed
+					height := func(index int) int {
+						return paragraphHeight(gtx, index, drawParagraph)
+					}
+					// Jumping to a paragraph? Then it starts at the focus bar
+					if p.jumpTo >= 0 {
+						p.position = scrollPosition{index: p.jumpTo}
+						p.scrollBy = -gtx.Metric.PxToDp(p.jumpShift)
+						p.jumpTo, p.jumpShift = -1, 0
+						p.movedByHand()
+						changed = true
+					}
+					// Scroll on from where we were, and start the list so that ends up at the focus bar.
+					// Moving also keeps the position within the script, in case it got shorter.
+					if len(p.paragraphList) > 0 {
+						p.position = p.position.move(float32(p.scrollBy)*gtx.Metric.PxPerDp, len(p.paragraphList), height)
+						p.scrollBy = 0
+						vizList.Position = p.position.listPosition(height(p.position.index), barTop)
+					}
 					// 2) ... then the list inside those margins ...
-					return vizList.Layout(gtx, len(paragraphList),
+					return vizList.Layout(gtx, len(p.paragraphList),
 						// 3) ... where each paragraph is a separate item
 						func(gtx C, index int) D {
-							// One label per paragraph
-							paragraph := material.Label(th, unit.Sp(float32(fontSize)), paragraphList[index])
-							// The text is centered
-							paragraph.Alignment = text.Middle
-							// Set color
-							paragraph.Color = myColor.foreground
-							// Return the laid out paragraph
-							return paragraph.Layout(gtx)
+							// Lay out the paragraph and remember its height
+							dims := drawParagraph(gtx, index)
+							paragraphHeights[index] = dims.Size.Y
+							return dims
 						},
 					)
 				},
 			)
 
+			// Now that the list is laid out, we know where each paragraph is.
+			// While editing, the text isn't laid out, so we remember where it was.
+			if !ui.edit.open {
+				p.onScreen = placeParagraphs(vizList.Position, paragraphHeights)
+				p.position = p.position.settle(p.onScreen, barTop)
+				// The subtitle clock knows where autoscroll left the text
+				if p.autoscroll {
+					p.captionAt = p.position
+				}
+			}
+
 			// ---------- THE FOCUS BAR ----------
 			// Draw the transparent red focus bar.
 			focusBar := clip.Rect{
-				Min: image.Pt(0, int(focusBarY)),
-				Max: image.Pt(gtx.Constraints.Max.X, int(focusBarY)+int(fontSize*1.5)),
+				Min: image.Pt(0, barTop),
+				Max: image.Pt(gtx.Constraints.Max.X, barBottom),
 			}.Push(&ops)
-			paint.ColorOp{Color: myColor.focusbar}.Add(&ops)
+			paint.ColorOp{Color: p.color.focusbar}.Add(&ops)
 			paint.PaintOp{}.Add(&ops)
 			focusBar.Pop()
 
+			// ---------- STATUS LINE ----------
+			// A small reminder of the speed in the corner.
+			// With an operator window, that's where the status is, and the talent sees only the text.
+			speedStatus := fmt.Sprintf("%.0f dp/s", float32(p.autospeed))
+			if p.hasCaptions() {
+				speedStatus = "subtitles " + formatClock(p.captionClock)
+			} else if p.wpmMode {
+				speedStatus = fmt.Sprintf("%.0f wpm", p.targetWPM)
+			}
+			pauseStatus := ""
+			if !p.autoscroll {
+				pauseStatus = "paused"
+			} else if gtx.Now.Before(p.holdUntil) {
+				pauseStatus = "pause " + formatClock(p.holdUntil.Sub(gtx.Now))
+			}
+			slotStatus := ""
+			if p.schedule != nil {
+				slotStatus = formatClock(p.schedule.timeLeft(gtx.Now)) + " left"
+			}
+			replayStatus := ""
+			if p.replay != nil {
+				replayStatus = "replay"
+			}
+			if len(p.windows) == 1 {
+				// Keep clear of the minimap
+				gtx := gtx
+				if p.showMinimap {
+					gtx.Constraints.Max.X -= gtx.Dp(minimapWidth)
+				}
+				layoutStatus(gtx, th, p.color.foreground, p.reloadWarning, p.segmentName(), replayStatus, speedStatus, slotStatus, pauseStatus)
+			}
+
+			// Done with the mirror
+			mirrorStack.Pop()
+
 			// ---------- REGISTERING EVENTS ----------
-			// registering events here work
-			event.Op(&ops, tag)
+			// The event area is registered outside the mirror, covering the whole window.
+			// That way clicks and scrolls are caught the same way whether the text is mirrored or not.
+			// While editing, clicks and scrolls are for the editor instead.
+			if !ui.edit.open {
+				eventArea := clip.Rect{Max: gtx.Constraints.Max}.Push(&ops)
+				event.Op(&ops, tag)
+				eventArea.Pop()
+			}
+
+			// ---------- MINIMAP ----------
+			// Along the edge, on top of the event area so it gets its own clicks. Never mirrored either.
+			if p.showMinimap && !ui.edit.open {
+				mini.layout(gtx, p, barTop)
+			}
+
+			// ---------- SECTION MENU ----------
+			// On top of everything, and never mirrored, since it's for the operator
+			if ui.menu.open {
+				if picked := ui.menu.layout(gtx, th, p.color, p.sections); picked >= 0 {
+					p.jumpTo = picked
+					ui.menu.open = false
+					gtx.Execute(op.InvalidateCmd{})
+				}
+			}
+
+			// ---------- SEARCH BOX ----------
+			if ui.search.open {
+				ui.search.layout(gtx, th, p.color, p.search)
+			}
+
+			// ---------- EDITOR ----------
+			// Within the margins, but never mirrored, since the text must be readable when typing
+			if ui.edit.open {
+				margins.Layout(gtx, func(gtx C) D {
+					return ui.edit.layout(gtx, th, p.color, p.fontSize, barTop)
+				})
+			}
+
+			// ---------- SHARING ----------
+			// Let the remote control know how things are, and the operator window if anything changed
+			rc.publish(p.state(), p.sections)
+			p.record(gtx.Now)
+			if changed || p.autoscroll {
+				p.redraw(w)
+			}
+			p.mu.Unlock()
 
 			// ---------- FINALIZE ----------
 			// Frame completes the FrameEvent by drawing the graphical operations from ops into the window.
@@ -374,6 +718,35 @@ func draw(w *app.Window) error {
 
 			// Should we shut down?
 		case app.DestroyEvent:
+			// Finish the recording
+			if err := p.recorder.close(); err != nil {
+				fmt.Printf("RECORD: could not save the recording: %v\n", err)
+			}
+			// Save the timing reports, one for each script
+			p.mu.Lock()
+			p.saveTimingReports()
+			p.mu.Unlock()
+			// Remember the settings for next time
+			if prefsPath != "" {
+				p.mu.Lock()
+				current := prefs
+				// Never so small that the preferences can't be read next time
+				current.FontSize = float32(max(p.fontSize, minFontSize))
+				current.TextWidth = float32(max(p.textWidth, minTextWidth))
+				current.FocusBarY = float32(p.focusBarY)
+				current.ColorMode = p.colorName()
+				current.Speed = float32(p.autospeed)
+				current.Dim = p.dim
+				current.Alignment = alignmentName(p.style.alignment)
+				current.LineHeight = p.style.lineHeight
+				current.ParagraphSpacing = p.style.spacing
+				current.WindowWidth = float32(windowWidth)
+				current.WindowHeight = float32(windowHeight)
+				p.mu.Unlock()
+				if err := current.save(prefsPath); err != nil {
+					fmt.Printf("PREFS : could not save preferences: %v\n", err)
+				}
+			}
 			return winE.Err
 		}
 	}
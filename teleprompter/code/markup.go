@@ -0,0 +1,372 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"gioui.org/font"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+	"gioui.org/x/styledtext"
+	"golang.org/x/image/math/fixed"
+)
+
+// Scripts ending in .md use a lightweight markup:
+//
+//	# Heading, ## Smaller heading
+//	Some *emphasis* and some **strong** words
+//	// A note for the director, hidden from the talent
+//
+// Everything else, like .txt files, is shown as plain text, line by line.
+
+// The kinds of paragraphs in a script
+type paragraphKind int
+
+const (
+	plainParagraph paragraphKind = iota
+	headingParagraph
+	noteParagraph
+	// A line with nothing but cues, see cues.go
+	cueParagraph
+)
+
+// span is a piece of a paragraph with a single style
+type span struct {
+	text     string
+	emphasis bool
+	strong   bool
+}
+
+// paragraph is one line of the script
+type paragraph struct {
+	kind paragraphKind
+	// 1 for #, 2 for ## and so on
+	level int
+	// The words without any markup
+	text string
+	// The styled pieces of the text. Empty when the whole paragraph is plain.
+	spans []span
+	// The cues in the line, taken out of the text
+	cues []cue
+	// When the paragraph is a caption from subtitles, its timing. Otherwise nil.
+	caption *caption
+}
+
+// spoken is the text the talent reads out loud, which is nothing for a note or a cue
+func (p paragraph) spoken() string {
+	if p.kind == noteParagraph || p.kind == cueParagraph {
+		return ""
+	}
+	return p.text
+}
+
+// isMarkup tells if a file should be read with markup
+func isMarkup(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// parseScript turns the lines of a script into paragraphs.
+// Without markup, every line is a plain paragraph exactly as written, except for the cues.
+func parseScript(lines []string, markup bool) []paragraph {
+	paragraphs := make([]paragraph, len(lines))
+	for i, line := range lines {
+		line, cues := parseCues(line)
+		switch {
+		case len(cues) > 0 && strings.TrimSpace(line) == "":
+			paragraphs[i] = paragraph{kind: cueParagraph}
+		case markup:
+			paragraphs[i] = parseLine(line)
+		default:
+			paragraphs[i] = paragraph{kind: plainParagraph, text: line}
+		}
+		paragraphs[i].cues = cues
+	}
+	return paragraphs
+}
+
+// parseLine reads the markup of a single line
+func parseLine(line string) paragraph {
+	trimmed := strings.TrimSpace(line)
+
+	// Notes for the director
+	if note, ok := strings.CutPrefix(trimmed, "//"); ok {
+		return paragraph{kind: noteParagraph, text: strings.TrimSpace(note)}
+	}
+
+	// Headings, from # to ######
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level > 0 && level <= 6 && len(trimmed) > level && trimmed[level] == ' ' {
+		spans := parseSpans(strings.TrimSpace(trimmed[level:]))
+		return paragraph{kind: headingParagraph, level: level, text: spansText(spans), spans: spans}
+	}
+
+	// Plain text, maybe with some emphasis
+	spans := parseSpans(line)
+	if len(spans) == 1 && !spans[0].emphasis && !spans[0].strong {
+		return paragraph{kind: plainParagraph, text: line}
+	}
+	return paragraph{kind: plainParagraph, text: spansText(spans), spans: spans}
+}
+
+// parseSpans splits a line on *emphasis* and **strong** markers.
+// A marker without a partner later in the line is kept as a plain star.
+func parseSpans(line string) []span {
+	spans := []span{}
+	var current strings.Builder
+	var emphasis, strong bool
+
+	// Finish the current span, and start a new one
+	flush := func() {
+		if current.Len() > 0 {
+			spans = append(spans, span{text: current.String(), emphasis: emphasis, strong: strong})
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		if strings.HasPrefix(rest, "**") && (strong || strings.Contains(rest[2:], "**")) {
+			flush()
+			strong = !strong
+			i += 2
+			continue
+		}
+		if rest[0] == '*' && (emphasis || strings.Contains(rest[1:], "*")) {
+			flush()
+			emphasis = !emphasis
+			i++
+			continue
+		}
+		current.WriteByte(line[i])
+		i++
+	}
+	flush()
+	return spans
+}
+
+// spansText joins the text of the spans, without markup
+func spansText(spans []span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// headingScale is how much larger a heading is than the text
+func headingScale(level int) float32 {
+	switch level {
+	case 1:
+		return 1.5
+	case 2:
+		return 1.3
+	}
+	return 1.15
+}
+
+// singleStyle tells if all the spans have the same style, and which.
+// A paragraph without spans is plain text.
+func singleStyle(spans []span) (span, bool) {
+	for _, s := range spans {
+		if s.emphasis != spans[0].emphasis || s.strong != spans[0].strong {
+			return span{}, false
+		}
+	}
+	if len(spans) == 0 {
+		return span{}, true
+	}
+	return spans[0], true
+}
+
+// spanFont is the font of a span. Headings and strong words are bold, unless the text is bolder already.
+func spanFont(th *material.Theme, s span, heading bool) font.Font {
+	f := font.Font{Typeface: th.Face, Weight: textWeight}
+	if s.emphasis {
+		f.Style = font.Italic
+	}
+	if s.strong || heading {
+		f.Weight = max(font.Bold, textWeight)
+	}
+	return f
+}
+
+// layoutParagraph draws a single paragraph of the script.
+// Notes are drawn in the note color if showNotes is set, otherwise they take no space at all.
+// Lines with only cues are never drawn.
+// The style sets the alignment, the line height and the space after the paragraph.
+func layoutParagraph(gtx C, th *material.Theme, p paragraph, fontSize unit.Sp, fg, noteColor color.NRGBA, style paragraphStyle, showNotes bool) D {
+	// The space after a paragraph with text, in the size of the text, even for headings
+	spaced := func(dims D) D {
+		if strings.TrimSpace(p.text) != "" {
+			dims.Size.Y += int(style.spacing * float32(gtx.Sp(fontSize)))
+		}
+		return dims
+	}
+
+	switch p.kind {
+	case cueParagraph:
+		return D{}
+
+	case noteParagraph:
+		if !showNotes {
+			return D{}
+		}
+		note := material.Label(th, fontSize*0.6, p.text)
+		note.Alignment = style.alignment
+		note.LineHeightScale = style.lineHeightScale()
+		note.Font.Style = font.Italic
+		note.Color = noteColor
+		return spaced(note.Layout(gtx))
+
+	case headingParagraph:
+		fontSize = fontSize * unit.Sp(headingScale(p.level))
+	}
+
+	// Text in a single style, like plain text or a heading
+	if s, ok := singleStyle(p.spans); ok {
+		// One label per paragraph
+		label := material.Label(th, fontSize, p.text)
+		// The text is centered, unless the style says otherwise
+		label.Alignment = style.alignment
+		label.LineHeightScale = style.lineHeightScale()
+		// Set color
+		label.Color = fg
+		label.Font = spanFont(th, s, p.kind == headingParagraph)
+		return spaced(label.Layout(gtx))
+	}
+
+	// Styled text, one style per span
+	styles := make([]styledtext.SpanStyle, len(p.spans))
+	for i, s := range p.spans {
+		styles[i] = styledtext.SpanStyle{
+			Font:    spanFont(th, s, p.kind == headingParagraph),
+			Size:    fontSize,
+			Color:   fg,
+			Content: s.text,
+		}
+	}
+	lineHeight := int(float32(gtx.Sp(fontSize)) * style.lineHeightScale())
+	return spaced(layoutStyled(gtx, th.Shaper, styles, style.alignment, lineHeight))
+}
+
+// Styled text.
+// Styled text knows nothing of line height, so a paragraph that mixes styles is broken into lines here,
+// at the spaces, and each line is drawn as styled text on its own, a line height below the one before.
+// That way its lines are as far apart as those of a label.
+
+// word is a word of styled text, with the spaces after it
+type word struct {
+	// Which span it's from
+	span int
+	text string
+	// How wide the word is, with and without the spaces after it
+	width, inked int
+}
+
+// splitWords splits the spans into words, and measures them
+func splitWords(gtx C, shaper *text.Shaper, styles []styledtext.SpanStyle) []word {
+	words := []word{}
+	for i, s := range styles {
+		for _, w := range strings.SplitAfter(s.Content, " ") {
+			if w == "" {
+				continue
+			}
+			words = append(words, word{
+				span:  i,
+				text:  w,
+				width: textWidth(gtx, shaper, s, w),
+				inked: textWidth(gtx, shaper, s, strings.TrimRight(w, " ")),
+			})
+		}
+	}
+	return words
+}
+
+// textWidth measures a piece of text in the style of a span, on a single line
+func textWidth(gtx C, shaper *text.Shaper, s styledtext.SpanStyle, str string) int {
+	shaper.LayoutString(text.Parameters{
+		Font:    s.Font,
+		PxPerEm: fixed.I(gtx.Sp(s.Size)),
+		Locale:  gtx.Locale,
+	}, str)
+	var width fixed.Int26_6
+	for g, ok := shaper.NextGlyph(); ok; g, ok = shaper.NextGlyph() {
+		width += g.Advance
+	}
+	return width.Ceil()
+}
+
+// wrapWords fills lines with as many words as fit the width.
+// The spaces at the end of a line may stick out, since they aren't seen.
+// A word too wide for any line gets a line of its own.
+func wrapWords(words []word, width int) [][]word {
+	lines := [][]word{}
+	var line []word
+	used := 0
+	for _, w := range words {
+		if len(line) > 0 && used+w.inked > width {
+			lines = append(lines, line)
+			line, used = nil, 0
+		}
+		line = append(line, w)
+		used += w.width
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lineStyles joins the words of a line back into spans, without the spaces at the end
+func lineStyles(styles []styledtext.SpanStyle, line []word) []styledtext.SpanStyle {
+	spans := []styledtext.SpanStyle{}
+	for i, w := range line {
+		if i > 0 && line[i-1].span == w.span {
+			spans[len(spans)-1].Content += w.text
+			continue
+		}
+		s := styles[w.span]
+		s.Content = w.text
+		spans = append(spans, s)
+	}
+	last := &spans[len(spans)-1]
+	last.Content = strings.TrimRight(last.Content, " ")
+	return spans
+}
+
+// layoutStyled draws styled text with lines lineHeight pixels apart
+func layoutStyled(gtx C, shaper *text.Shaper, styles []styledtext.SpanStyle, alignment text.Alignment, lineHeight int) D {
+	gtx.Constraints.Min = image.Point{}
+	lines := wrapWords(splitWords(gtx, shaper, styles), gtx.Constraints.Max.X)
+	var size image.Point
+	for i, line := range lines {
+		styled := styledtext.Text(shaper, lineStyles(styles, line)...)
+		styled.Alignment = alignment
+		stack := op.Offset(image.Pt(0, size.Y)).Push(gtx.Ops)
+		dims := styled.Layout(gtx, nil)
+		stack.Pop()
+		size.X = max(size.X, dims.Size.X)
+		// The last line is as high as its letters, like the last line of a label.
+		// A word too wide for the line is broken over several, which take all the room they need.
+		switch {
+		case i == len(lines)-1:
+			size.Y += dims.Size.Y
+		case line[0].inked > gtx.Constraints.Max.X:
+			size.Y += max(lineHeight, dims.Size.Y)
+		default:
+			size.Y += lineHeight
+		}
+	}
+	return D{Size: size}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// Lines in a single style are laid out as labels, so the line height applies to them
+func TestSingleStyle(t *testing.T) {
+	tests := []struct {
+		line   string
+		single bool
+		want   span
+	}{
+		{"Plain text", true, span{}},
+		{"# A heading", true, span{text: "A heading"}},
+		{"## A *quiet* heading", false, span{}},
+		{"*All of it in italics*", true, span{text: "All of it in italics", emphasis: true}},
+		{"**Strong** and *emphasised*", false, span{}},
+		{"A star * on its own", true, span{}},
+	}
+	for _, tt := range tests {
+		got, ok := singleStyle(parseLine(tt.line).spans)
+		if ok != tt.single || got != tt.want {
+			t.Errorf("%q: singleStyle = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.single)
+		}
+	}
+}
+
+// Words go on a line as long as they fit, not counting the space after the last one
+func TestWrapWords(t *testing.T) {
+	words := []word{
+		{text: "one ", width: 40, inked: 30},
+		{text: "two ", width: 40, inked: 30},
+		{text: "three", width: 50, inked: 50},
+		{text: "enormous", width: 200, inked: 200},
+		{text: "end", width: 30, inked: 30},
+	}
+	tests := []struct {
+		width int
+		want  []int
+	}{
+		{110, []int{2, 1, 1, 1}},
+		{130, []int{3, 1, 1}},
+		{1000, []int{5}},
+	}
+	for _, tt := range tests {
+		lines := wrapWords(words, tt.width)
+		got := []int{}
+		for _, line := range lines {
+			got = append(got, len(line))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("at width %d, the lines have %v words, want %v", tt.width, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("at width %d, the lines have %v words, want %v", tt.width, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// The line height applies to lines that mix styles, like it does to labels
+func TestStyledLineHeight(t *testing.T) {
+	th := material.NewTheme()
+	p := parseLine("Some *emphasised* words in a paragraph long enough to take **several** lines")
+	height := func(lineHeight float32) int {
+		var ops op.Ops
+		gtx := layout.Context{
+			Ops:         &ops,
+			Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+			Constraints: layout.Constraints{Max: image.Pt(300, 1000)},
+		}
+		style := paragraphStyle{alignment: text.Middle, lineHeight: lineHeight}
+		return layoutParagraph(gtx, th, p, 20, colorDark.foreground, colorDark.note, style, false).Size.Y
+	}
+	tight, loose := height(1), height(2)
+	// At 20 pixels a letter, the lines are 24 pixels apart, and 48 at twice the line height
+	if grown := loose - tight; grown < 2*24 || grown%24 != 0 {
+		t.Errorf("twice the line height took the paragraph from %d to %d pixels high", tight, loose)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"unicode/utf8"
+
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// Minimap.
+// A slim bar along the right edge shows the whole script, so we can see where we are in it.
+// Each paragraph is a line, as long as the paragraph is, with gaps for the blank lines.
+// The part of the script in the window is a lighter box, the focus bar is a line across,
+// and sections are marked at the edge. Click or drag in the bar to go there. B hides it.
+
+// How wide the minimap is
+const minimapWidth = unit.Dp(14)
+
+// About how many letters fit on a line, to guess how tall each paragraph is.
+// Only the paragraphs on screen have been laid out, so for the rest a guess is all we have.
+const minimapLetters = 40
+
+// minimap is the bar in the talent's window
+type minimap struct {
+	// Where each paragraph starts, as a fraction of the script, and the end at the last place
+	starts []float32
+}
+
+// measure guesses where each paragraph is in the script, from how long it is
+func (m *minimap) measure(paragraphs []paragraph) {
+	m.starts = m.starts[:0]
+	var total float32
+	for _, para := range paragraphs {
+		m.starts = append(m.starts, total)
+		total += 1 + float32(utf8.RuneCountInString(para.text)/minimapLetters)
+	}
+	m.starts = append(m.starts, total)
+	for i := range m.starts {
+		m.starts[i] /= max(total, 1)
+	}
+}
+
+// at is how far into the script we are, given a paragraph and how far into that paragraph
+func (m *minimap) at(index int, within float32) float32 {
+	if index < 0 || index+1 >= len(m.starts) {
+		return 0
+	}
+	within = min(max(within, 0), 1)
+	return m.starts[index] + within*(m.starts[index+1]-m.starts[index])
+}
+
+// paragraphAt is the paragraph that far into the script
+func (m *minimap) paragraphAt(fraction float32) int {
+	for i := 0; i+1 < len(m.starts); i++ {
+		if fraction < m.starts[i+1] {
+			return i
+		}
+	}
+	return len(m.starts) - 2
+}
+
+// update seeks when the bar is clicked or dragged. It returns true if anything changed.
+func (m *minimap) update(gtx C, p *prompter) bool {
+	changed := false
+	for {
+		ev, ok := gtx.Event(pointer.Filter{Target: m, Kinds: pointer.Press | pointer.Drag})
+		if !ok {
+			break
+		}
+		// A replay is in charge of where the text is
+		if p.replay != nil || len(m.starts) < 2 {
+			continue
+		}
+		e := ev.(pointer.Event)
+		fraction := e.Position.Y / float32(gtx.Constraints.Max.Y)
+		p.jumpTo = max(m.paragraphAt(fraction), 0)
+		changed = true
+	}
+	return changed
+}
+
+// layout draws the minimap along the right edge of the window
+func (m *minimap) layout(gtx C, p *prompter, barTop int) D {
+	width, height := gtx.Dp(minimapWidth), gtx.Constraints.Max.Y
+	m.measure(p.paragraphList)
+	y := func(fraction float32) int { return int(fraction * float32(height)) }
+
+	defer op.Offset(image.Pt(gtx.Constraints.Max.X-width, 0)).Push(gtx.Ops).Pop()
+	defer clip.Rect{Max: image.Pt(width, height)}.Push(gtx.Ops).Pop()
+	background := p.color.foreground
+	background.A = background.A / 10
+	paint.Fill(gtx.Ops, background)
+	event.Op(gtx.Ops, m)
+
+	// The paragraphs, as long as they are wide
+	ink := p.color.foreground
+	ink.A = ink.A / 3
+	for i, para := range p.paragraphList {
+		letters := utf8.RuneCountInString(strings.TrimSpace(para.text))
+		if letters == 0 {
+			continue
+		}
+		length := max(width*min(letters, 2*minimapLetters)/(2*minimapLetters), gtx.Dp(2))
+		top := y(m.starts[i])
+		bottom := max(y(m.starts[i+1])-1, top+1)
+		paint.FillShape(gtx.Ops, ink, clip.Rect{Min: image.Pt(0, top), Max: image.Pt(length, bottom)}.Op())
+	}
+
+	// The sections, marked at the edge
+	mark := p.color.focusbar
+	mark.A = 0xff
+	for _, s := range p.sections {
+		top := y(m.at(s.index, 0))
+		paint.FillShape(gtx.Ops, mark, clip.Rect{Min: image.Pt(width-gtx.Dp(4), top), Max: image.Pt(width, top+gtx.Dp(2))}.Op())
+	}
+
+	// The part of the script in the window, from the first paragraph seen to the last
+	if len(p.onScreen) > 0 {
+		first, last := p.onScreen[0], p.onScreen[len(p.onScreen)-1]
+		top := y(m.at(first.index, float32(-first.top)/float32(max(first.height, 1))))
+		bottom := y(m.at(last.index, float32(height-last.top)/float32(max(last.height, 1))))
+		viewport := p.color.foreground
+		viewport.A = viewport.A / 5
+		paint.FillShape(gtx.Ops, viewport, clip.Rect{Min: image.Pt(0, top), Max: image.Pt(width, max(bottom, top+gtx.Dp(4)))}.Op())
+	}
+
+	// The focus bar, as a line across
+	for _, pos := range p.onScreen {
+		if barTop < pos.top || barTop >= pos.top+pos.height {
+			continue
+		}
+		focus := y(m.at(pos.index, float32(barTop-pos.top)/float32(max(pos.height, 1))))
+		paint.FillShape(gtx.Ops, mark, clip.Rect{Min: image.Pt(0, focus-gtx.Dp(1)), Max: image.Pt(width, focus+gtx.Dp(1))}.Op())
+		break
+	}
+
+	return D{Size: image.Pt(width, height)}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"gioui.org/f32"
+)
+
+// Mirror mode, for rigs where the talent reads a reflection in a beam-splitter glass.
+// The text can be flipped left-right, upside-down or both.
+type mirrorMode struct {
+	horizontal bool
+	vertical   bool
+}
+
+// parseMirror reads the -mirror flag.
+// Valid values are "" or "none", "h", "v" and "hv"
+func parseMirror(s string) (mirrorMode, error) {
+	switch s {
+	case "", "none":
+		return mirrorMode{}, nil
+	case "h":
+		return mirrorMode{horizontal: true}, nil
+	case "v":
+		return mirrorMode{vertical: true}, nil
+	case "hv", "vh":
+		return mirrorMode{horizontal: true, vertical: true}, nil
+	}
+	return mirrorMode{}, fmt.Errorf("unknown mirror mode %q, use none, h, v or hv", s)
+}
+
+// next cycles through the modes: none -> h -> v -> hv -> none
+func (m mirrorMode) next() mirrorMode {
+	switch {
+	case !m.horizontal && !m.vertical:
+		return mirrorMode{horizontal: true}
+	case m.horizontal && !m.vertical:
+		return mirrorMode{vertical: true}
+	case !m.horizontal && m.vertical:
+		return mirrorMode{horizontal: true, vertical: true}
+	}
+	return mirrorMode{}
+}
+
+// String gives the same names as parseMirror accepts
+func (m mirrorMode) String() string {
+	switch {
+	case m.horizontal && m.vertical:
+		return "hv"
+	case m.horizontal:
+		return "h"
+	case m.vertical:
+		return "v"
+	}
+	return "none"
+}
+
+// transform flips everything drawn inside it around the center of an area of the given size.
+// A scale of -1 along an axis is a mirror along that axis.
+func (m mirrorMode) transform(size image.Point) f32.Affine2D {
+	factor := f32.Pt(1, 1)
+	if m.horizontal {
+		factor.X = -1
+	}
+	if m.vertical {
+		factor.Y = -1
+	}
+	center := f32.Pt(float32(size.X)/2, float32(size.Y)/2)
+	return f32.Affine2D{}.Scale(center, factor)
+}
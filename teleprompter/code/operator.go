@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"gioui.org/app"
+	"gioui.org/font"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// The operator console.
+// With -operator, a second window shows the script in small print, where we are in it,
+// how fast we go, and buttons to control it all. The talent's window then shows only the text.
+// Both windows work on the same prompter, so whatever happens in one is seen in the other.
+// The Cues button shows only the lines with cues in them, to check them before going on air.
+
+// How many lines of the script to show above the one under the focus bar
+const operatorLinesAbove = 3
+
+// The buttons in the operator console
+type operatorButtons struct {
+	previous, slower, startStop, faster, next widget.Clickable
+	cues                                      widget.Clickable
+}
+
+// drawOperator is the draw function for the operator's window
+func drawOperator(w *app.Window, p *prompter) error {
+	// The buttons, and a menu to pick sections from, a search box and an editor
+	var buttons operatorButtons
+	var ui overlays
+
+	// The script, and where each line of it was drawn in the last frame
+	var script layout.List
+	scriptHeights := map[int]int{}
+	var scriptLines []paragraphPos
+	// The lines shown in the script, which is all of them, or only those with cues
+	var rows []int
+	var showCues bool
+
+	// th defines the material design style, with our fonts
+	th := newTheme()
+
+	// ops are the operations from the UI
+	var ops op.Ops
+
+	// Scrolling and clicking in the script have a tag of their own
+	var scriptTag = "Operator script"
+
+	for {
+		switch winE := w.Event().(type) {
+
+		case app.FrameEvent:
+			gtx := app.NewContext(&ops, winE)
+			p.mu.Lock()
+
+			// ---------- Handle input ----------
+			changed := false
+
+			// Clicked a button?
+			for act, button := range map[action]*widget.Clickable{
+				actionPreviousSection: &buttons.previous,
+				actionSlowDown:        &buttons.slower,
+				actionStartStop:       &buttons.startStop,
+				actionSpeedUp:         &buttons.faster,
+				actionNextSection:     &buttons.next,
+			} {
+				if button.Clicked(gtx) {
+					p.do(act, 1)
+					changed = true
+				}
+			}
+
+			// Switched between the script and the cues?
+			if buttons.cues.Clicked(gtx) {
+				showCues = !showCues
+			}
+
+			// Scrolled or clicked in the script?
+			for {
+				ev, ok := gtx.Event(
+					pointer.Filter{
+						Target:  scriptTag,
+						Kinds:   pointer.Scroll | pointer.Press,
+						ScrollY: pointer.ScrollRange{Min: -1, Max: +1},
+					},
+				)
+				if !ok {
+					break
+				}
+				e := ev.(pointer.Event)
+				switch e.Kind {
+				case pointer.Scroll:
+					// Moves the text for the talent, just like the wheel in their window
+					p.scroll(unit.Dp(e.Scroll.Y * float32(p.fontSize)))
+				case pointer.Press:
+					// Sends the line clicked to the focus bar
+					for _, line := range scriptLines {
+						if int(e.Position.Y) >= line.top && int(e.Position.Y) < line.top+line.height {
+							p.jumpTo = rows[line.index]
+						}
+					}
+				}
+				changed = true
+			}
+
+			// Pressed a key, or typed in the search box or the editor? The keys work the same as in the talent's window
+			if p.handleKeyboard(gtx, &ui) {
+				changed = true
+			}
+
+			// ---------- LAYOUT ----------
+			paint.Fill(&ops, th.Bg)
+			current := p.focus()
+			layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx C) D {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						return layoutOperatorStatus(gtx, th, p, current)
+					}),
+					layout.Rigid(func(gtx C) D {
+						return layout.Inset{Top: unit.Dp(12), Bottom: unit.Dp(12)}.Layout(gtx, func(gtx C) D {
+							return layoutOperatorButtons(gtx, th, &buttons, p.autoscroll)
+						})
+					}),
+					layout.Flexed(1, func(gtx C) D {
+						// While editing, the editor takes the place of the script
+						if ui.edit.open {
+							return ui.edit.layout(gtx, th, colorLight, unit.Sp(18), 0)
+						}
+						// Which lines to show
+						rows = rows[:0]
+						first := 0
+						for i, paragraph := range p.paragraphList {
+							if showCues && len(paragraph.cues) == 0 {
+								continue
+							}
+							if i <= current {
+								first = len(rows)
+							}
+							rows = append(rows, i)
+						}
+						// The script follows the talent, with the current line a little way down
+						script.Axis = layout.Vertical
+						script.Position = layout.Position{First: max(first-operatorLinesAbove, 0)}
+						clear(scriptHeights)
+						dims := script.Layout(gtx, len(rows), func(gtx C, index int) D {
+							i := rows[index]
+							mark, marked := p.search.highlight(i)
+							if i == current {
+								mark, marked = colorLight.focusbar, true
+							}
+							dims := layoutScriptLine(gtx, th, p.paragraphList[i], i, mark, marked)
+							scriptHeights[index] = dims.Size.Y
+							return dims
+						})
+						scriptLines = placeParagraphs(script.Position, scriptHeights)
+
+						// The script catches scrolls and clicks on top of the list
+						area := clip.Rect{Max: dims.Size}.Push(gtx.Ops)
+						event.Op(gtx.Ops, scriptTag)
+						area.Pop()
+						return dims
+					}),
+				)
+			})
+
+			// ---------- SEARCH BOX ----------
+			if ui.search.open {
+				ui.search.layout(gtx, th, colorLight, p.search)
+			}
+
+			// ---------- SECTION MENU ----------
+			if ui.menu.open {
+				if picked := ui.menu.layout(gtx, th, colorLight, p.sections); picked >= 0 {
+					p.jumpTo = picked
+					ui.menu.open = false
+					changed = true
+				}
+			}
+
+			// The clock keeps going, even when nothing else happens
+			if !p.started.IsZero() {
+				gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Second)})
+			}
+			if changed {
+				p.redraw(w)
+			}
+			p.mu.Unlock()
+			winE.Frame(&ops)
+
+		case app.DestroyEvent:
+			return winE.Err
+		}
+	}
+}
+
+// layoutOperatorStatus shows where we are, how fast we go, and for how long we've been going
+func layoutOperatorStatus(gtx C, th *material.Theme, p *prompter, current int) D {
+	position := fmt.Sprintf("Paragraph %d of %d", current+1, len(p.paragraphList))
+	if segment := p.segmentName(); segment != "" {
+		position = segment + "  ·  " + position
+	}
+	if section := sectionAt(p.sections, current); section != "" {
+		position += "  ·  " + section
+	}
+
+	speed := fmt.Sprintf("%.0f dp/s", float32(p.autospeed))
+	if p.wpmMode {
+		speed = fmt.Sprintf("%.0f wpm", p.targetWPM)
+	}
+	switch {
+	case p.autoscroll && time.Now().Before(p.holdUntil):
+		speed = "Pause cue, " + formatClock(time.Until(p.holdUntil)) + " left, at " + speed
+	case p.autoscroll:
+		speed = "Running at " + speed
+	default:
+		speed = "Paused at " + speed
+	}
+
+	clock := "Not started"
+	if !p.started.IsZero() {
+		clock = "Elapsed " + formatClock(time.Since(p.started))
+	}
+	if p.schedule != nil {
+		clock += "  ·  " + formatClock(p.schedule.timeLeft(time.Now())) + " left"
+	}
+
+	lines := []string{position, speed, clock}
+	if p.reloadWarning != "" {
+		lines = append(lines, p.reloadWarning)
+	}
+	children := []layout.FlexChild{}
+	for _, line := range lines {
+		label := material.Body1(th, line)
+		label.MaxLines = 1
+		children = append(children, layout.Rigid(label.Layout))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// layoutOperatorButtons lays out the buttons in a row
+func layoutOperatorButtons(gtx C, th *material.Theme, buttons *operatorButtons, autoscroll bool) D {
+	startStop := "Start"
+	if autoscroll {
+		startStop = "Stop"
+	}
+	button := func(clickable *widget.Clickable, label string) layout.FlexChild {
+		return layout.Flexed(1, func(gtx C) D {
+			return layout.UniformInset(unit.Dp(2)).Layout(gtx, material.Button(th, clickable, label).Layout)
+		})
+	}
+	return layout.Flex{}.Layout(gtx,
+		button(&buttons.previous, "◀ Section"),
+		button(&buttons.slower, "Slower"),
+		button(&buttons.startStop, startStop),
+		button(&buttons.faster, "Faster"),
+		button(&buttons.next, "Section ▶"),
+		button(&buttons.cues, "Cues"),
+	)
+}
+
+// layoutScriptLine draws one paragraph of the script in small print, with its line number.
+// The line under the talent's focus bar, and lines found by a search, are marked with the given color.
+// Notes for the director are always shown here, dimmed, and so are the cues, after the text.
+func layoutScriptLine(gtx C, th *material.Theme, p paragraph, index int, mark color.NRGBA, marked bool) D {
+	dim := th.Fg
+	dim.A = dim.A / 2
+
+	number := material.Caption(th, fmt.Sprintf("%d", index+1))
+	number.Color = dim
+	line := material.Body2(th, p.text)
+	switch p.kind {
+	case headingParagraph:
+		line.Font.Weight = font.Bold
+	case noteParagraph, cueParagraph:
+		line.Font.Style = font.Italic
+		line.Color = dim
+	}
+	cues := material.Body2(th, cueText(p.cues))
+	cues.Color = dim
+
+	return layout.Background{}.Layout(gtx,
+		func(gtx C) D {
+			if marked {
+				paint.FillShape(gtx.Ops, mark, clip.Rect{Max: gtx.Constraints.Min}.Op())
+			}
+			return D{Size: gtx.Constraints.Min}
+		},
+		func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(2)}.Layout(gtx, func(gtx C) D {
+				return layout.Flex{Alignment: layout.Baseline}.Layout(gtx,
+					layout.Rigid(func(gtx C) D {
+						gtx.Constraints.Min.X = gtx.Dp(36)
+						return number.Layout(gtx)
+					}),
+					layout.Flexed(1, line.Layout),
+					layout.Rigid(func(gtx C) D {
+						if len(p.cues) == 0 {
+							return D{}
+						}
+						return layout.Inset{Left: unit.Dp(8)}.Layout(gtx, cues.Layout)
+					}),
+				)
+			})
+		},
+	)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+)
+
+// Words per minute mode.
+// Instead of a fixed speed, the speed is worked out from the words passing the focus bar,
+// so that a long line of small words and a short line of long words take equally long to read.
+const (
+	// The pace we start at, a relaxed speaking pace
+	defaultWPM float32 = 150
+	// One press on F or S changes the pace this much. Shift gives five steps.
+	wpmStep float32 = 10
+)
+
+// countWords counts the words in a paragraph.
+// A blank line counts as one word, so a pause between paragraphs takes a little time as well.
+func countWords(paragraph string) int {
+	n := len(strings.Fields(paragraph))
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// countAllWords counts the spoken words in each paragraph of the script.
+// A line with only cues is never seen, so it has no words at all.
+func countAllWords(paragraphs []paragraph) []int {
+	words := make([]int, len(paragraphs))
+	for i, p := range paragraphs {
+		if p.kind == cueParagraph {
+			continue
+		}
+		words[i] = countWords(p.spoken())
+	}
+	return words
+}
+
+// wpmSpeed returns the speed, in pixels per second, that moves the words under
+// the focus bar past it at wpm words per minute.
+// Paragraphs partially under the bar count with the part of their words that are covered.
+func wpmSpeed(wpm float32, placed []paragraphPos, words []int, barTop, barBottom int) float32 {
+	if wpm <= 0 || barBottom <= barTop {
+		return 0
+	}
+	var covered float32
+	for _, p := range placed {
+		if p.height <= 0 || p.index >= len(words) {
+			continue
+		}
+		overlap := min(p.top+p.height, barBottom) - max(p.top, barTop)
+		if overlap <= 0 {
+			continue
+		}
+		covered += float32(overlap) / float32(p.height) * float32(words[p.index])
+	}
+	if covered == 0 {
+		return 0
+	}
+	// It takes covered/wpm minutes to read what's under the bar.
+	// In that time the text shall move the height of the bar.
+	seconds := covered / wpm * 60
+	return float32(barBottom-barTop) / seconds
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Playlists.
+// A show has several segments, each with a script of its own. They are given as several -file flags,
+// as file names after the flags, or in a playlist file ending in .playlist or .m3u, with one file per line:
+//
+//	# The evening news
+//	intro.md
+//	weather.txt
+//
+// Files in a playlist are found relative to the playlist. Lines starting with # are comments.
+// A file named - is read from stdin.
+
+// fileList collects the -file flags
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fileList) Set(name string) error {
+	*f = append(*f, name)
+	return nil
+}
+
+// isPlaylist tells if a file lists other files, rather than being a script itself
+func isPlaylist(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".playlist", ".m3u":
+		return true
+	}
+	return false
+}
+
+// expandPlaylists replaces the playlists among the files with the files they list
+func expandPlaylists(files []string) ([]string, error) {
+	expanded := []string{}
+	for _, name := range files {
+		if !isPlaylist(name) {
+			expanded = append(expanded, name)
+			continue
+		}
+		listed, err := readPlaylist(name)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, listed...)
+	}
+	return expanded, nil
+}
+
+// readPlaylist reads the files listed in a playlist
+func readPlaylist(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	files := []string{}
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(name), line)
+		}
+		files = append(files, line)
+	}
+	return files, lines.Err()
+}
+
+// stdin can only be read once, so we keep what was read
+var stdinOnce sync.Once
+var stdinText []byte
+var stdinErr error
+
+// readSource reads a script file, or stdin if the name is -
+func readSource(filename string) ([]byte, error) {
+	if filename != "-" {
+		return os.ReadFile(filename)
+	}
+	stdinOnce.Do(func() {
+		stdinText, stdinErr = io.ReadAll(os.Stdin)
+	})
+	return bytes.Clone(stdinText), stdinErr
+}
+
+// script is one segment of the show.
+// Its position and timing are kept here while another script is shown.
+type script struct {
+	filename   string
+	paragraphs []paragraph
+	position   scrollPosition
+	timing     paragraphTiming
+	bookmarks  bookmarks
+}
+
+// name is what the segment is called on screen
+func (s *script) name() string {
+	if s.filename == "-" {
+		return "stdin"
+	}
+	return filepath.Base(s.filename)
+}
+
+// switchScript shows another script, where it was when we last left it
+func (p *prompter) switchScript(index int) {
+	if index < 0 || index >= len(p.scripts) || index == p.currentScript {
+		return
+	}
+	// Remember where we are in this one
+	old := p.scripts[p.currentScript]
+	old.paragraphs, old.position, old.timing = p.paragraphList, p.position, p.timing
+
+	p.currentScript = index
+	s := p.scripts[index]
+	fmt.Printf("SCRIPT: %s\n", s.name())
+	p.paragraphList, p.timing = s.paragraphs, s.timing
+	p.setText(s.paragraphs)
+	p.position, p.scrollBy = s.position, 0
+	p.jumpTo, p.jumpShift = -1, 0
+	p.holdUntil = time.Time{}
+	p.reloadWarning = ""
+	if p.schedule != nil {
+		p.schedule.replan()
+	}
+}
+
+// segmentName is the name of the current script, and where it is in the playlist.
+// With a single script, it's empty.
+func (p *prompter) segmentName() string {
+	if len(p.scripts) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d/%d)", p.scripts[p.currentScript].name(), p.currentScript+1, len(p.scripts))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// paragraphPos is where a paragraph was drawn on screen, in pixels from the top of the list
+type paragraphPos struct {
+	index  int
+	top    int
+	height int
+}
+
+// placeParagraphs works out where the paragraphs ended up on screen.
+// The list only tells us which paragraph is first and how far it is scrolled,
+// so together with the height of each paragraph laid out, we can place the rest.
+func placeParagraphs(pos layout.Position, heights map[int]int) []paragraphPos {
+	indexes := make([]int, 0, len(heights))
+	for index := range heights {
+		if index >= pos.First {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+
+	placed := make([]paragraphPos, 0, len(indexes))
+	top := -pos.Offset
+	for _, index := range indexes {
+		placed = append(placed, paragraphPos{index: index, top: top, height: heights[index]})
+		top += heights[index]
+	}
+	return placed
+}
+
+// focusParagraph finds the paragraph at the middle of the focus bar,
+// and how far into it the middle is, from 0 at its top to 1 at its bottom.
+// If no paragraph is under the bar, the index is -1.
+func focusParagraph(placed []paragraphPos, barTop, barBottom int) (index int, fraction float32) {
+	center := (barTop + barBottom) / 2
+	for _, p := range placed {
+		if p.height > 0 && center >= p.top && center < p.top+p.height {
+			return p.index, float32(center-p.top) / float32(p.height)
+		}
+	}
+	return -1, 0
+}
+
+// scrollPosition is where we are in the script: the paragraph at the top of the focus bar,
+// and how far into that paragraph the bar is, from 0 at its top to 1 at its bottom.
+// It points into the text rather than at a distance in pixels, so when the font, the width
+// or the window changes and the text flows anew, the same words stay at the focus bar.
+type scrollPosition struct {
+	index    int
+	fraction float32
+}
+
+// value is the position as a single number, like 12.25 for a quarter into paragraph 12.
+// That's how it's recorded and sent to the remote control.
+func (s scrollPosition) value() float32 {
+	return float32(s.index) + s.fraction
+}
+
+// positionFromValue is the position from a single number, the opposite of value
+func positionFromValue(v float32) scrollPosition {
+	v = max(v, 0)
+	return scrollPosition{index: int(v), fraction: v - float32(int(v))}
+}
+
+// move scrolls the position on by a distance in pixels, which may take it into other paragraphs.
+// height measures a paragraph, and count is how many there are. The position never leaves the script.
+func (s scrollPosition) move(distance float32, count int, height func(index int) int) scrollPosition {
+	if count == 0 {
+		return scrollPosition{}
+	}
+	index := min(max(s.index, 0), count-1)
+	at := s.fraction*float32(height(index)) + distance
+	// Back up, or go on, one paragraph at a time
+	for at < 0 && index > 0 {
+		index--
+		at += float32(height(index))
+	}
+	for at >= float32(height(index)) && index < count-1 {
+		at -= float32(height(index))
+		index++
+	}
+	h := float32(height(index))
+	if h <= 0 {
+		return scrollPosition{index: index}
+	}
+	return scrollPosition{index: index, fraction: min(max(at/h, 0), 1)}
+}
+
+// listPosition is where the list starts, so the position ends up at the top of the focus bar.
+// height is how tall the paragraph at the position is.
+// The offset is often negative, and then the list lays out the paragraphs above by itself.
+func (s scrollPosition) listPosition(height, barTop int) layout.Position {
+	return layout.Position{First: s.index, Offset: int(s.fraction*float32(height)) - barTop}
+}
+
+// settle takes the position from where the list placed the paragraphs.
+// The list keeps the text from scrolling past the start or the end of the script, so it has the last word,
+// except for less than a pixel, which is only rounding, and would make slow scrolling stall if we let it.
+func (s scrollPosition) settle(placed []paragraphPos, barTop int) scrollPosition {
+	for _, p := range placed {
+		if p.height <= 0 || barTop < p.top || barTop >= p.top+p.height {
+			continue
+		}
+		into := float32(barTop - p.top)
+		if d := into - s.fraction*float32(p.height); p.index == s.index && d > -1 && d < 1 {
+			return s
+		}
+		return scrollPosition{index: p.index, fraction: into / float32(p.height)}
+	}
+	return s
+}
+
+// paragraphHeight measures a paragraph, which is laid out but never drawn
+func paragraphHeight(gtx C, index int, layoutParagraph layout.ListElement) int {
+	// The same constraints as the list gives each paragraph
+	gtx.Constraints.Min.Y = 0
+	gtx.Constraints.Max.Y = 1e6
+	macro := op.Record(gtx.Ops)
+	height := layoutParagraph(gtx, index).Size.Y
+	macro.Stop()
+	return height
+}
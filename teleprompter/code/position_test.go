@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+
+	"gioui.org/layout"
+)
+
+// Paragraphs of 100, 0, 50 and 200 pixels. The second is a line with only cues, which takes no room.
+var testHeights = []int{100, 0, 50, 200}
+
+func testHeight(index int) int {
+	return testHeights[index]
+}
+
+func TestPositionMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     scrollPosition
+		distance float32
+		want     scrollPosition
+	}{
+		{"within a paragraph", scrollPosition{0, 0.5}, 30, scrollPosition{0, 0.8}},
+		{"down past a cue line", scrollPosition{0, 0.5}, 60, scrollPosition{2, 0.2}},
+		{"down onto a cue line goes on past it", scrollPosition{0, 0.5}, 50, scrollPosition{2, 0}},
+		{"down over several paragraphs", scrollPosition{0, 0}, 250, scrollPosition{3, 0.5}},
+		{"up past a cue line", scrollPosition{2, 0.2}, -20, scrollPosition{0, 0.9}},
+		{"up over several paragraphs", scrollPosition{3, 0.5}, -240, scrollPosition{0, 0.1}},
+		{"up past the start", scrollPosition{0, 0.1}, -50, scrollPosition{0, 0}},
+		{"down past the end", scrollPosition{3, 0.5}, 500, scrollPosition{3, 1}},
+		{"nowhere", scrollPosition{2, 0.2}, 0, scrollPosition{2, 0.2}},
+		{"after the script got shorter", scrollPosition{9, 0.5}, 0, scrollPosition{3, 0.5}},
+	}
+	for _, tt := range tests {
+		got := tt.from.move(tt.distance, len(testHeights), testHeight)
+		if got.index != tt.want.index || !near(got.fraction, tt.want.fraction) {
+			t.Errorf("%s: %+v moved %v = %+v, want %+v", tt.name, tt.from, tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestPositionMoveEmpty(t *testing.T) {
+	if got := (scrollPosition{2, 0.5}).move(10, 0, testHeight); got != (scrollPosition{}) {
+		t.Errorf("in an empty script: %+v", got)
+	}
+	// A script of nothing but cues has no room to move in
+	cues := func(int) int { return 0 }
+	if got := (scrollPosition{0, 0}).move(10, 3, cues); got != (scrollPosition{2, 0}) {
+		t.Errorf("in a script of cues: %+v", got)
+	}
+}
+
+func TestPositionValue(t *testing.T) {
+	for _, v := range []float32{0, 3, 12.25, 7.5} {
+		if got := positionFromValue(v).value(); !near(got, v) {
+			t.Errorf("%v comes back as %v", v, got)
+		}
+	}
+	if got := positionFromValue(-2); got != (scrollPosition{}) {
+		t.Errorf("a negative position is %+v, want the start", got)
+	}
+}
+
+func TestListPosition(t *testing.T) {
+	got := scrollPosition{2, 0.25}.listPosition(200, 150)
+	if want := (layout.Position{First: 2, Offset: -100}); got != want {
+		t.Errorf("listPosition = %+v, want %+v", got, want)
+	}
+}
+
+func TestPositionSettle(t *testing.T) {
+	// The list starts 20 pixels into the first paragraph
+	heights := map[int]int{}
+	for i, h := range testHeights {
+		heights[i] = h
+	}
+	placed := placeParagraphs(layout.Position{First: 0, Offset: 20}, heights)
+	const barTop = 100
+	tests := []struct {
+		name string
+		from scrollPosition
+		want scrollPosition
+	}{
+		{"already there", scrollPosition{2, 0.4}, scrollPosition{2, 0.4}},
+		{"less than a pixel off is kept", scrollPosition{2, 0.41}, scrollPosition{2, 0.41}},
+		{"less than a pixel off the other way", scrollPosition{2, 0.39}, scrollPosition{2, 0.39}},
+		{"moved by the list", scrollPosition{2, 0.1}, scrollPosition{2, 0.4}},
+		{"another paragraph", scrollPosition{0, 0.5}, scrollPosition{2, 0.4}},
+	}
+	for _, tt := range tests {
+		got := tt.from.settle(placed, barTop)
+		if got.index != tt.want.index || !near(got.fraction, tt.want.fraction) {
+			t.Errorf("%s: %+v settles at %+v, want %+v", tt.name, tt.from, got, tt.want)
+		}
+	}
+	// Nothing at the focus bar, so nothing to go by
+	if got := (scrollPosition{1, 0.3}).settle(placed, -50); got != (scrollPosition{1, 0.3}) {
+		t.Errorf("with no paragraph at the bar: %+v", got)
+	}
+}
+
+// Slow scrolling moves less than a pixel a frame, and mustn't be undone by the list rounding to whole pixels
+func TestPositionSlowScroll(t *testing.T) {
+	heights := map[int]int{}
+	for i, h := range testHeights {
+		heights[i] = h
+	}
+	const barTop = 0
+	pos := scrollPosition{3, 0}
+	for frame := 0; frame < 100; frame++ {
+		pos = pos.move(0.3, len(testHeights), testHeight)
+		list := pos.listPosition(testHeights[pos.index], barTop)
+		pos = pos.settle(placeParagraphs(list, heights), barTop)
+	}
+	if !near(pos.fraction*200, 30) {
+		t.Errorf("after 100 frames of 0.3 pixels: %v pixels into the paragraph, want 30", pos.fraction*200)
+	}
+}
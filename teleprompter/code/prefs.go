@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gioui.org/unit"
+)
+
+// preferences are the settings kept from one show to the next.
+// They are stored as JSON in the user's config directory, and loaded on startup.
+// Command line flags take precedence over the file, and the file over the defaults.
+type preferences struct {
+	FontSize  float32 `json:"fontSize"`
+	TextWidth float32 `json:"textWidth"`
+	FocusBarY float32 `json:"focusBarY"`
+	ColorMode string  `json:"colorMode"`
+	// The autoscroll speed, in Dp per second
+	Speed float32 `json:"speed"`
+	// How much the text fades away from the focus bar, from 0 to 1
+	Dim float32 `json:"dim"`
+	// Start, middle or end, a multiple of the usual line height, and the space after paragraphs in font sizes
+	Alignment        string  `json:"alignment"`
+	LineHeight       float32 `json:"lineHeight"`
+	ParagraphSpacing float32 `json:"paragraphSpacing"`
+	// The window size, in Dp
+	WindowWidth  float32 `json:"windowWidth"`
+	WindowHeight float32 `json:"windowHeight"`
+	// Keys for the actions that shouldn't use the default keys, see keys.go
+	Keys map[string][]string `json:"keys,omitempty"`
+	// Color themes besides the built-in ones, see themes.go
+	Themes []themeConfig `json:"themes,omitempty"`
+	// Font files, and the face and weight of the text, see fonts.go
+	Fonts      []string `json:"fonts,omitempty"`
+	FontFace   string   `json:"fontFace,omitempty"`
+	FontWeight string   `json:"fontWeight,omitempty"`
+}
+
+// The smallest font size and text width, so the text never shrinks away to nothing
+const (
+	minFontSize  unit.Sp = 8
+	minTextWidth unit.Dp = 100
+)
+
+// defaultPreferences are used for anything not in the file
+func defaultPreferences() preferences {
+	return preferences{
+		FontSize:     35,
+		TextWidth:    550,
+		FocusBarY:    170,
+		ColorMode:    "dark",
+		Speed:        float32(defaultSpeed),
+		Dim:          0.5,
+		Alignment:    "middle",
+		LineHeight:   1,
+		WindowWidth:  650,
+		WindowHeight: 600,
+	}
+}
+
+// preferencesPath is where the preferences are stored if nothing else is given
+func preferencesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "teleprompter", "preferences.json"), nil
+}
+
+// loadPreferences reads the preferences from a file.
+// A missing file is fine, that just means the defaults are used.
+// Settings in the file that don't make sense are set back to the defaults, with a warning,
+// since a bad value saved last time shouldn't keep the prompter from starting.
+func loadPreferences(path string) (preferences, error) {
+	prefs := defaultPreferences()
+	f, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	if err := json.Unmarshal(f, &prefs); err != nil {
+		return prefs, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, err := range prefs.repair() {
+		fmt.Printf("PREFS : %s: %v, so the default is used\n", path, err)
+	}
+	return prefs, nil
+}
+
+// preferenceProblem is a setting that doesn't make sense, and how to set it back to the default
+type preferenceProblem struct {
+	err   error
+	reset func(defaults preferences)
+}
+
+// problems lists the settings that don't make sense
+func (p *preferences) problems() []preferenceProblem {
+	found := []preferenceProblem{}
+	add := func(err error, reset func(defaults preferences)) {
+		found = append(found, preferenceProblem{err: err, reset: reset})
+	}
+	if p.FontSize < float32(minFontSize) {
+		add(fmt.Errorf("fontSize must be %v or more, not %v", float32(minFontSize), p.FontSize),
+			func(d preferences) { p.FontSize = d.FontSize })
+	}
+	if p.TextWidth < float32(minTextWidth) {
+		add(fmt.Errorf("textWidth must be %v or more, not %v", float32(minTextWidth), p.TextWidth),
+			func(d preferences) { p.TextWidth = d.TextWidth })
+	}
+	if p.Dim < 0 || p.Dim > 1 {
+		add(fmt.Errorf("dim must be from 0 to 1, not %v", p.Dim),
+			func(d preferences) { p.Dim = d.Dim })
+	}
+	if _, err := parseAlignment(p.Alignment); err != nil {
+		add(err, func(d preferences) { p.Alignment = d.Alignment })
+	}
+	if p.LineHeight < minLineHeight || p.LineHeight > maxLineHeight {
+		add(fmt.Errorf("lineHeight must be from %v to %v, not %v", minLineHeight, maxLineHeight, p.LineHeight),
+			func(d preferences) { p.LineHeight = d.LineHeight })
+	}
+	if p.ParagraphSpacing < 0 || p.ParagraphSpacing > maxSpacing {
+		add(fmt.Errorf("paragraphSpacing must be from 0 to %v, not %v", maxSpacing, p.ParagraphSpacing),
+			func(d preferences) { p.ParagraphSpacing = d.ParagraphSpacing })
+	}
+	if _, err := parseWeight(p.FontWeight); err != nil {
+		add(err, func(d preferences) { p.FontWeight = d.FontWeight })
+	}
+	if p.ColorMode == "" {
+		add(errors.New("colorMode must name a theme"),
+			func(d preferences) { p.ColorMode = d.ColorMode })
+	}
+	return found
+}
+
+// check makes sure the preferences make sense
+func (p preferences) check() error {
+	errs := []error{}
+	for _, problem := range p.problems() {
+		errs = append(errs, problem.err)
+	}
+	return errors.Join(errs...)
+}
+
+// repair sets everything that doesn't make sense back to the default, and tells what it was
+func (p *preferences) repair() []error {
+	defaults := defaultPreferences()
+	errs := []error{}
+	for _, problem := range p.problems() {
+		problem.reset(defaults)
+		errs = append(errs, problem.err)
+	}
+	return errs
+}
+
+// save writes the preferences to a file.
+// Like a script, it's written safely, so a crash never leaves half a file behind.
+func (p preferences) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A bad value saved last time is set back to the default, and doesn't keep the prompter from starting
+func TestLoadPreferencesRepairs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	data := `{"fontSize": 0, "textWidth": -10, "dim": 2, "lineHeight": 1.5, "colorMode": "light"}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prefs, err := loadPreferences(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := defaultPreferences()
+	if prefs.FontSize != defaults.FontSize || prefs.TextWidth != defaults.TextWidth || prefs.Dim != defaults.Dim {
+		t.Errorf("bad values became %v, %v and %v, want the defaults", prefs.FontSize, prefs.TextWidth, prefs.Dim)
+	}
+	if prefs.LineHeight != 1.5 || prefs.ColorMode != "light" {
+		t.Errorf("good values became %v and %q", prefs.LineHeight, prefs.ColorMode)
+	}
+	if err := prefs.check(); err != nil {
+		t.Errorf("after the repair: %v", err)
+	}
+}
+
+// Values from the command line are checked, but not put right behind our back
+func TestPreferencesCheck(t *testing.T) {
+	prefs := defaultPreferences()
+	if err := prefs.check(); err != nil {
+		t.Errorf("the defaults: %v", err)
+	}
+	prefs.FontSize = 2
+	if err := prefs.check(); err == nil {
+		t.Error("a font size of 2 was let through")
+	}
+}
+
+// The keys can't shrink the text to nothing, so it can always be saved and read again
+func TestSizeLimits(t *testing.T) {
+	p := &prompter{fontSize: minFontSize + 1, textWidth: minTextWidth + 10}
+	for range 10 {
+		p.do(actionFontSmaller, 5)
+		p.do(actionNarrower, 5)
+	}
+	if p.fontSize != minFontSize || p.textWidth != minTextWidth {
+		t.Errorf("font size %v and width %v, want %v and %v", p.fontSize, p.textWidth, minFontSize, minTextWidth)
+	}
+}
+
+// Saved preferences load back, and the temporary file doesn't stay behind
+func TestSavePreferences(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "teleprompter")
+	path := filepath.Join(dir, "preferences.json")
+	prefs := defaultPreferences()
+	prefs.LineHeight = 1.5
+	for range 2 {
+		if err := prefs.save(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := loadPreferences(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LineHeight != 1.5 {
+		t.Errorf("the line height came back as %v", loaded.LineHeight)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("%d files were left in the folder, want only the preferences", len(files))
+	}
+}
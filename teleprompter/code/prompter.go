@@ -0,0 +1,632 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gioui.org/app"
+	"gioui.org/io/key"
+	"gioui.org/unit"
+)
+
+// prompter is the state of the teleprompter.
+// The talent window and the operator window share one prompter, but each runs
+// its own event loop, so nothing in here may be touched without locking mu.
+type prompter struct {
+	mu sync.Mutex
+
+	// The scripts of the show, and which of them is shown, see playlist.go
+	scripts       []*script
+	currentScript int
+
+	// A []paragraph to hold the speech, one paragraph per line
+	paragraphList []paragraph
+	// The number of words in each paragraph
+	paragraphWords []int
+	// The sections of the script
+	sections []section
+
+	// Where we are in the text, see position.go,
+	// and how far to scroll from there when the text is laid out next
+	position scrollPosition
+	scrollBy unit.Dp
+	// y-position for red focusBar
+	focusBarY unit.Dp
+	// The pixels per Dp and Sp of the talent window, to place the focus bar among the paragraphs
+	metric unit.Metric
+	// width of text area
+	textWidth unit.Dp
+	// fontSize
+	fontSize unit.Sp
+
+	// Are we auto scrolling?
+	// The speed is in Dp per second
+	autoscroll bool
+	autospeed  unit.Dp
+	// When was the last autoscrolled frame drawn?
+	// We move the text by how much time has passed since then.
+	lastFrame time.Time
+	// When autoscroll was first started, to show the elapsed time
+	started time.Time
+
+	// Words per minute mode, set from the command line and toggled with P.
+	// In this mode F and S change targetWPM, and autospeed follows the words under the focus bar.
+	wpmMode   bool
+	targetWPM float32
+
+	// With a time slot from the command line, the pace is planned to finish on time.
+	schedule *schedule
+
+	// Where the paragraphs were on screen in the talent window, in the last frame
+	onScreen []paragraphPos
+
+	// The paragraph to move to the focus bar in the next frame, or -1.
+	// Normally the paragraph jumped to starts at the top of the focus bar.
+	// jumpShift moves it this many pixels further down.
+	jumpTo    int
+	jumpShift int
+
+	// Cues in the script, see cues.go.
+	// Cues up to and including paragraph cuesDone have been carried out.
+	// cuesMoved says the text was moved by other means than autoscroll, so the cues passed are skipped.
+	cuesDone  int
+	cuesMoved bool
+	// A pause cue holds the scrolling until this time
+	holdUntil time.Time
+	// A slow cue halves the speed while this paragraph is under the focus bar, or -1
+	slowAt int
+
+	// With subtitles, the clock the captions follow, see subtitles.go.
+	// captionFrame is when the clock was last moved on, and captionAt where the text was then.
+	captionClock time.Duration
+	captionFrame time.Time
+	captionAt    scrollPosition
+
+	// How long each paragraph has been under the focus bar, see timing.go
+	timing paragraphTiming
+
+	// Recording or replaying a rehearsal, see record.go. Both are nil unless asked for.
+	// actions are the actions done since the last frame was recorded.
+	recorder *recorder
+	replay   *replay
+	actions  []string
+
+	// The last search, see search.go
+	search searchResult
+
+	// If the speech couldn't be reloaded, this says why
+	reloadWarning string
+
+	// Colors and mirroring
+	color  colorMode
+	mirror mirrorMode
+
+	// Show the minimap along the edge, see minimap.go
+	showMinimap bool
+
+	// How much the text fades away from the focus bar, see highlight.go
+	dim float32
+
+	// Alignment and spacing of the text, see typesetting.go
+	style paragraphStyle
+
+	// The windows showing the prompter, all of which are redrawn when something changes
+	windows []*app.Window
+}
+
+// newPrompter sets up the prompter from the preferences and the command line.
+// It starts with the first script.
+func newPrompter(scripts []*script) *prompter {
+	p := &prompter{
+		focusBarY: unit.Dp(prefs.FocusBarY),
+		textWidth: unit.Dp(prefs.TextWidth),
+		fontSize:  unit.Sp(prefs.FontSize),
+		autospeed: unit.Dp(prefs.Speed),
+		wpmMode:   startWPM > 0,
+		targetWPM: defaultWPM,
+		jumpTo:    -1,
+		cuesDone:  -1,
+		slowAt:    -1,
+		// Define a color to start with. We like dark, unless the preferences say otherwise
+		color:       colorDark,
+		mirror:      startMirror,
+		showMinimap: true,
+		dim:         prefs.Dim,
+		style: paragraphStyle{
+			lineHeight: prefs.LineHeight,
+			spacing:    prefs.ParagraphSpacing,
+		},
+	}
+	p.style.alignment, _ = parseAlignment(prefs.Alignment)
+	if at, ok := findTheme(themes, prefs.ColorMode); ok {
+		p.color = themes[at]
+	}
+	if p.wpmMode {
+		p.targetWPM = startWPM
+	}
+	// A time slot is paced in words per minute, so we switch to that mode
+	if slotDuration > 0 {
+		p.schedule = newSchedule(slotDuration)
+		p.wpmMode = true
+	}
+	p.scripts = scripts
+	p.setText(scripts[0].paragraphs)
+	return p
+}
+
+// setText replaces the speech, and everything worked out from it
+func (p *prompter) setText(paragraphs []paragraph) {
+	p.timing.remap(p.paragraphList, paragraphs)
+	p.paragraphList = paragraphs
+	p.paragraphWords = countAllWords(paragraphs)
+	p.sections = findSections(paragraphs, sectionPattern)
+	p.onScreen = nil
+	p.cuesMoved = true
+	// Matches are paragraph numbers, so they must be found again
+	p.search.matches = findMatches(paragraphs, p.search.query)
+	p.search.selected = min(p.search.selected, max(len(p.search.matches)-1, 0))
+}
+
+// redraw asks all windows except the given one to draw a new frame
+func (p *prompter) redraw(except *app.Window) {
+	for _, w := range p.windows {
+		if w != except {
+			w.Invalidate()
+		}
+	}
+}
+
+// focusBarSpan is where the focus bar is, from its y-position and one and a half lines down.
+// It's in pixels of the talent window, like the paragraphs on screen.
+func (p *prompter) focusBarSpan() (barTop, barBottom int) {
+	barTop = p.metric.Dp(p.focusBarY)
+	return barTop, barTop + p.metric.Sp(p.fontSize*1.5)
+}
+
+// focus is the paragraph under the focus bar, or -1
+func (p *prompter) focus() int {
+	barTop, barBottom := p.focusBarSpan()
+	current, _ := focusParagraph(p.onScreen, barTop, barBottom)
+	return current
+}
+
+// colorName is the name of the color theme, for the remote control and the preferences
+func (p *prompter) colorName() string {
+	return p.color.name
+}
+
+// scroll moves the text by hand
+func (p *prompter) scroll(distance unit.Dp) {
+	if p.replay != nil {
+		return
+	}
+	p.scrollBy += distance
+	p.movedByHand()
+}
+
+// moveTo puts the text back at a position, by hand
+func (p *prompter) moveTo(pos scrollPosition) {
+	if p.replay != nil {
+		return
+	}
+	p.position, p.scrollBy = pos, 0
+	p.movedByHand()
+}
+
+// movedByHand is called whenever the text is moved by hand
+func (p *prompter) movedByHand() {
+	p.cuesMoved = true
+	// Moved by hand, so the pace must be planned again from here
+	if p.schedule != nil {
+		p.schedule.replan()
+	}
+}
+
+// do carries out an action from a key.
+// stepSize is 1, or 5 if Shift was held down.
+// During a replay, the timeline is in charge, and nothing is done.
+func (p *prompter) do(act action, stepSize unit.Dp) {
+	if p.replay != nil {
+		return
+	}
+	p.actions = append(p.actions, string(act))
+	// Ctrl and a number sets a bookmark, and the number alone goes back to it
+	if n, set, ok := act.bookmark(); ok {
+		if set {
+			p.setBookmark(n)
+		} else {
+			p.goToBookmark(n)
+		}
+		return
+	}
+	switch act {
+	// Start / stop
+	case actionStartStop:
+		// Space during a pause cue means don't wait any longer, and scrolling goes on
+		if p.autoscroll && time.Now().Before(p.holdUntil) {
+			p.holdUntil = time.Time{}
+			break
+		}
+		p.autoscroll = !p.autoscroll
+		if p.autoscroll && p.autospeed <= 0 {
+			p.autospeed = stepSize * defaultSpeed
+		}
+		p.holdUntil = time.Time{}
+
+	// Move the focusBar Up. The position is at the focus bar, so it moves along to keep the text still.
+	case actionFocusUp:
+		p.focusBarY = p.focusBarY - stepSize
+		p.scrollBy -= stepSize
+
+	// Move the focusBar Down
+	case actionFocusDown:
+		p.focusBarY = p.focusBarY + stepSize
+		p.scrollBy += stepSize
+
+	// Scroll up
+	case actionScrollUp:
+		p.scroll(-stepSize * 4)
+	case actionPageUp:
+		p.scroll(-stepSize * 100)
+
+	// Scroll down
+	case actionScrollDown:
+		p.scroll(stepSize * 4)
+	case actionPageDown:
+		p.scroll(stepSize * 100)
+
+	// Faster scrollspeed
+	case actionSpeedUp:
+		p.autoscroll = true
+		if p.wpmMode {
+			p.targetWPM += float32(stepSize) * wpmStep
+			if p.schedule != nil {
+				p.schedule.nudge(float32(stepSize) * wpmStep)
+			}
+		} else {
+			p.autospeed += stepSize * speedStep
+		}
+
+	// Slower scrollspeed
+	case actionSlowDown:
+		if p.wpmMode {
+			p.targetWPM -= float32(stepSize) * wpmStep
+			if p.schedule != nil {
+				p.schedule.nudge(-float32(stepSize) * wpmStep)
+			}
+			if p.targetWPM <= 0 {
+				p.targetWPM = 0
+				p.autoscroll = false
+			}
+		} else {
+			if p.autospeed > 0 {
+				p.autospeed -= stepSize * speedStep
+			}
+			if p.autospeed <= 0 {
+				p.autospeed = 0
+				p.autoscroll = false
+			}
+		}
+
+	// Switch between words per minute and a fixed speed
+	case actionToggleWPM:
+		p.wpmMode = !p.wpmMode
+		if p.wpmMode && p.targetWPM <= 0 {
+			p.targetWPM = defaultWPM
+		}
+
+	// To increase the fontsize
+	case actionFontBigger:
+		p.fontSize = p.fontSize + unit.Sp(stepSize)
+
+	// To decrease the fontsize
+	case actionFontSmaller:
+		p.fontSize = max(p.fontSize-unit.Sp(stepSize), minFontSize)
+
+	// Widen text to be displayed
+	case actionWider:
+		p.textWidth = p.textWidth + stepSize*10
+
+	// Narrow text to be displayed
+	case actionNarrower:
+		p.textWidth = max(p.textWidth-stepSize*10, minTextWidth)
+
+	// Alignment, line height and the gap between paragraphs
+	case actionAlign:
+		p.style.nextAlignment()
+	case actionLineHeightUp:
+		p.style.changeLineHeight(float32(stepSize))
+	case actionLineHeightDown:
+		p.style.changeLineHeight(-float32(stepSize))
+	case actionSpacingUp:
+		p.style.changeSpacing(float32(stepSize))
+	case actionSpacingDown:
+		p.style.changeSpacing(-float32(stepSize))
+
+	// Switch to the next color theme
+	case actionToggleColor:
+		p.nextTheme()
+
+	// Switch mirror mode, for use behind a beam-splitter glass
+	case actionToggleMirror:
+		p.mirror = p.mirror.next()
+		fmt.Printf("MIRROR: %v\n", p.mirror)
+
+	// Show or hide the minimap
+	case actionToggleMinimap:
+		p.showMinimap = !p.showMinimap
+
+	// Show how long each paragraph took so far
+	case actionTimingReport:
+		p.reportTiming()
+
+	// Go to the next or previous script in the playlist
+	case actionNextScript:
+		p.switchScript(p.currentScript + 1)
+	case actionPreviousScript:
+		p.switchScript(p.currentScript - 1)
+
+	// Jump to the next or previous section
+	case actionNextSection:
+		p.jumpTo = nextSection(p.sections, p.focus())
+	case actionPreviousSection:
+		p.jumpTo = previousSection(p.sections, p.focus())
+
+	// After a search, go to the next or previous match
+	case actionNextMatch:
+		p.nextMatch(+1)
+	case actionPreviousMatch:
+		p.nextMatch(-1)
+	}
+}
+
+// overlays are what a window can show on top of the text, each window its own
+type overlays struct {
+	menu   sectionMenu
+	search searchBox
+	edit   editBox
+}
+
+// handleKeyboard deals with the keys, and with what was typed in the search box or the editor.
+// The first to ask for a key gets it, so the search box and the editor go before the key bindings,
+// or else Enter and the arrows would never reach them. It returns true if anything changed.
+func (p *prompter) handleKeyboard(gtx C, ui *overlays) bool {
+	typed := ui.search.update(gtx, p)
+	edited := ui.edit.update(gtx, p)
+	pressed := p.handleKeys(gtx, ui)
+	return typed || edited || pressed
+}
+
+// handleKeys deals with the keys pressed since last frame, in either window.
+// While the section menu is open, scrolling moves in the menu and Enter picks a section.
+// While the search box or the editor is open, only cancel and save are handled here, since the rest is typed into it.
+// It returns true if any key was pressed. During a replay, keys are ignored.
+func (p *prompter) handleKeys(gtx C, ui *overlays) bool {
+	if p.replay != nil {
+		return false
+	}
+	// While typing in the search box or the editor, the only keys for us are those that close or save it
+	keyFilters := keyBindings.filters()
+	if ui.search.open || ui.edit.open {
+		keyFilters = keyBindings.filters(actionCancel, actionSave)
+	}
+	pressed := false
+	for {
+		ev, ok := gtx.Event(keyFilters...)
+		if !ok {
+			break
+		}
+		fmt.Printf("KEY   : %+v\n", ev)
+		e := ev.(key.Event)
+		if e.State != key.Press {
+			continue
+		}
+		pressed = true
+		act, _ := keyBindings.lookup(e, len(p.search.matches) > 0)
+
+		// Set stepsize
+		var stepSize unit.Dp = 1
+		if e.Modifiers.Contain(key.ModShift) {
+			stepSize = 5
+		}
+
+		if ui.edit.open {
+			switch act {
+			case actionCancel:
+				ui.edit.cancel(gtx)
+			case actionSave:
+				ui.edit.finish(gtx, p)
+			}
+			continue
+		}
+		if ui.search.open {
+			if act == actionCancel {
+				ui.search.cancel(gtx, p)
+			}
+			continue
+		}
+		if ui.menu.open {
+			switch {
+			case act == actionScrollUp:
+				ui.menu.move(p.sections, -1)
+			case act == actionScrollDown:
+				ui.menu.move(p.sections, +1)
+			case act == actionConfirm:
+				// The sections may have changed while the menu was open, by a reload or another script
+				if len(p.sections) > 0 {
+					ui.menu.selected = min(ui.menu.selected, len(p.sections)-1)
+					p.jumpTo = p.sections[ui.menu.selected].index
+				}
+				ui.menu.open = false
+			case act == actionCancel || act == actionSectionMenu:
+				ui.menu.open = false
+			}
+			continue
+		}
+		switch {
+		case act == actionSectionMenu:
+			ui.menu.show(p.sections, p.focus())
+			continue
+		case act == actionSearch:
+			ui.search.show(p)
+			continue
+		case act == actionEditParagraph || act == actionEditScript:
+			if err := ui.edit.start(p, act == actionEditScript); err != nil {
+				p.reloadWarning = "Can't edit: " + err.Error()
+				fmt.Printf("EDIT  : %v\n", err)
+			}
+			continue
+		// Esc forgets the search
+		case act == actionCancel:
+			p.search = searchResult{}
+			continue
+		// With nothing to pick or save, Enter and Ctrl+S do nothing
+		case act == actionConfirm || act == actionSave:
+			continue
+		}
+		p.do(act, stepSize)
+	}
+	return pressed
+}
+
+// command carries out a command from the remote control
+func (p *prompter) command(cmd remoteCommand) {
+	fmt.Printf("REMOTE: %+v\n", cmd)
+	if p.replay != nil {
+		return
+	}
+	p.actions = append(p.actions, "remote "+cmd.action)
+	switch cmd.action {
+	case "start":
+		p.autoscroll = true
+	case "stop":
+		p.autoscroll = false
+	case "toggle":
+		p.autoscroll = !p.autoscroll
+	case "speed":
+		p.wpmMode = cmd.name == "wpm"
+		if p.wpmMode {
+			p.targetWPM = float32(cmd.value)
+		} else {
+			p.autospeed = unit.Dp(cmd.value)
+		}
+	case "paragraph":
+		p.jumpTo = min(int(cmd.value), len(p.paragraphList)) - 1
+	case "section":
+		p.jumpTo = findSection(p.sections, cmd.name)
+		if p.jumpTo < 0 {
+			fmt.Printf("REMOTE: no section called %q\n", cmd.name)
+		}
+	case "fontsize":
+		p.fontSize = max(unit.Sp(cmd.value), minFontSize)
+	case "color":
+		p.nextTheme()
+	}
+}
+
+// reload puts in a new version of the speech.
+// The paragraph under the focus bar is found by its text, and kept where it is.
+// A script that isn't shown just gets its new text.
+func (p *prompter) reload(reload scriptReload) {
+	if reload.script != p.currentScript {
+		s := p.scripts[reload.script]
+		if reload.err != nil {
+			fmt.Printf("RELOAD: %s: %v\n", s.name(), reload.err)
+			return
+		}
+		s.timing.remap(s.paragraphs, reload.paragraphs)
+		s.paragraphs = reload.paragraphs
+		fmt.Printf("RELOAD: %s, %d paragraphs\n", s.name(), len(s.paragraphs))
+		return
+	}
+	if reload.err != nil {
+		p.reloadWarning = "Reload failed: " + reload.err.Error()
+		fmt.Printf("RELOAD: %v\n", reload.err)
+		return
+	}
+	barTop, _ := p.focusBarSpan()
+	if current := p.focus(); current >= 0 {
+		anchor, moved := findAnchor(p.paragraphList, reload.paragraphs, current)
+		p.jumpTo, p.jumpShift = anchor, 0
+		// Still there? Then it shall stay exactly where it was on screen
+		for _, pos := range p.onScreen {
+			if pos.index == current && !moved {
+				p.jumpShift = pos.top - barTop
+			}
+		}
+	}
+	p.setText(reload.paragraphs)
+	p.reloadWarning = ""
+	fmt.Printf("RELOAD: %d paragraphs\n", len(p.paragraphList))
+}
+
+// reportTiming prints the timing report of the script on screen, and saves them all if -report was given
+func (p *prompter) reportTiming() {
+	writeTimingTable(os.Stdout, timingReport(p.paragraphList, p.timing.seconds))
+	p.saveTimingReports()
+}
+
+// saveTimingReports saves the timing report of each script, if -report was given.
+// With a playlist, each script gets its own, numbered in the order of the playlist.
+func (p *prompter) saveTimingReports() {
+	if reportPath == "" {
+		return
+	}
+	// The script on screen keeps its timing here, so it's put back with the script first
+	s := p.scripts[p.currentScript]
+	s.paragraphs, s.timing = p.paragraphList, p.timing
+	for i, s := range p.scripts {
+		name := reportPath
+		if len(p.scripts) > 1 {
+			name = segmentReportName(reportPath, i, s)
+		}
+		if err := saveTimingReport(name, timingReport(s.paragraphs, s.timing.seconds)); err != nil {
+			fmt.Printf("REPORT: could not save the timing report of %s: %v\n", s.name(), err)
+		}
+	}
+}
+
+// record adds this frame to the timeline, if we're recording.
+// Each action since the last frame gets a line of its own.
+func (p *prompter) record(now time.Time) {
+	if p.recorder == nil {
+		p.actions = p.actions[:0]
+		return
+	}
+	sample := timelineSample{
+		Position:   p.position.value(),
+		Autospeed:  float32(p.autospeed),
+		Autoscroll: p.autoscroll,
+	}
+	actions := p.actions
+	if len(actions) == 0 {
+		actions = []string{""}
+	}
+	for _, action := range actions {
+		sample.Action = action
+		if err := p.recorder.record(now, sample); err != nil {
+			fmt.Printf("RECORD: %v\n", err)
+			p.recorder = nil
+		}
+	}
+	p.actions = p.actions[:0]
+}
+
+// state sums up the prompter, for the remote control and the operator window
+func (p *prompter) state() prompterState {
+	current := p.focus()
+	return prompterState{
+		Autoscroll: p.autoscroll,
+		Speed:      float32(p.autospeed),
+		WPMMode:    p.wpmMode,
+		WPM:        p.targetWPM,
+		Position:   p.position.value(),
+		Paragraph:  current + 1,
+		Paragraphs: len(p.paragraphList),
+		Section:    sectionAt(p.sections, current),
+		FontSize:   float32(p.fontSize),
+		Color:      p.colorName(),
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// keyboard runs frames of a window with a keyboard, but no screen
+type keyboard struct {
+	router input.Router
+	p      *prompter
+	ui     overlays
+	th     *material.Theme
+}
+
+func newKeyboard(t *testing.T, lines ...string) *keyboard {
+	t.Helper()
+	var err error
+	if keyBindings, err = newBindings(nil); err != nil {
+		t.Fatal(err)
+	}
+	p := &prompter{jumpTo: -1, fontSize: 20}
+	p.setText(parseScript(lines, false))
+	k := &keyboard{p: p, th: material.NewTheme()}
+	k.frame()
+	return k
+}
+
+// frame handles the keys like the windows do, and lays out the overlays that are open
+func (k *keyboard) frame() {
+	var ops op.Ops
+	gtx := layout.Context{
+		Ops:         &ops,
+		Source:      k.router.Source(),
+		Now:         time.Now(),
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(800, 600)),
+	}
+	k.p.handleKeyboard(gtx, &k.ui)
+	if k.ui.search.open {
+		k.ui.search.layout(gtx, k.th, colorDark, k.p.search)
+	}
+	if k.ui.edit.open {
+		k.ui.edit.layout(gtx, k.th, colorDark, k.p.fontSize, 0)
+	}
+	k.router.Frame(&ops)
+}
+
+// press presses and lets go of a key, and runs a frame for it, and one more for what follows
+func (k *keyboard) press(name key.Name, mods key.Modifiers) {
+	k.router.Queue(
+		key.Event{Name: name, Modifiers: mods, State: key.Press},
+		key.Event{Name: name, Modifiers: mods, State: key.Release},
+	)
+	k.frame()
+	k.frame()
+}
+
+// Enter in the search box reaches the search box, not the key bindings
+func TestSearchEnter(t *testing.T) {
+	k := newKeyboard(t, "alpha", "bravo", "charlie", "bravo two")
+	k.press("/", 0)
+	if !k.ui.search.open {
+		t.Fatal("/ didn't open the search box")
+	}
+	k.ui.search.editor.SetText("bravo")
+	k.p.searchFor("bravo")
+	k.p.jumpTo = -1
+
+	k.press(key.NameReturn, 0)
+	if k.ui.search.open {
+		t.Error("Enter didn't close the search box")
+	}
+	if k.p.jumpTo != 1 || len(k.p.search.matches) != 2 {
+		t.Errorf("after Enter: going to %d with matches %v, want 1 and two matches", k.p.jumpTo, k.p.search.matches)
+	}
+	// n and N go between the matches, and once they are forgotten n narrows the text again
+	k.press("N", 0)
+	if k.p.jumpTo != 3 {
+		t.Errorf("n went to %d, want 3", k.p.jumpTo)
+	}
+	k.press("N", key.ModShift)
+	if k.p.jumpTo != 1 {
+		t.Errorf("N went to %d, want 1", k.p.jumpTo)
+	}
+	k.press(key.NameEscape, 0)
+	k.p.textWidth = 500
+	k.press("N", 0)
+	if k.p.textWidth != 490 {
+		t.Errorf("after the search, n made the width %v, want 490", k.p.textWidth)
+	}
+}
+
+// Esc in the search box goes back, and the keys work as usual afterwards
+func TestSearchEscape(t *testing.T) {
+	k := newKeyboard(t, "alpha", "bravo")
+	k.press("/", 0)
+	k.press(key.NameEscape, 0)
+	if k.ui.search.open {
+		t.Fatal("Esc didn't close the search box")
+	}
+	k.press(key.NameSpace, 0)
+	if !k.p.autoscroll {
+		t.Error("Space didn't start autoscroll after the search")
+	}
+}
+
+// editScript sets up a script in a file, with the second paragraph at the focus bar
+func editScript(t *testing.T, text string) (*keyboard, string) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "speech.txt")
+	if err := os.WriteFile(filename, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paragraphs, err := loadText(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := newKeyboard(t)
+	k.p.scripts = []*script{{filename: filename, paragraphs: paragraphs}}
+	k.p.setText(paragraphs)
+	k.p.focusBarY = 110
+	k.p.onScreen = []paragraphPos{{index: 0, top: 0, height: 100}, {index: 1, top: 100, height: 100}}
+	return k, filename
+}
+
+// readFile reads a file in a test
+func readFile(t *testing.T, filename string) string {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// Enter in the paragraph editor saves
+func TestEditParagraphEnter(t *testing.T) {
+	k, filename := editScript(t, "alpha\nbravo\ncharlie\n")
+	k.press("E", 0)
+	if !k.ui.edit.open || k.ui.edit.editor.Text() != "bravo" {
+		t.Fatalf("E opened the editor %v, with %q", k.ui.edit.open, k.ui.edit.editor.Text())
+	}
+	k.ui.edit.editor.SetText("BRAVO")
+	k.press(key.NameReturn, 0)
+	if k.ui.edit.open {
+		t.Error("Enter didn't save and close the editor")
+	}
+	if got := readFile(t, filename); got != "alpha\nBRAVO\ncharlie\n" {
+		t.Errorf("saved %q", got)
+	}
+}
+
+// The arrows and Enter move and type in the editor of the whole script, rather than scroll
+func TestEditScriptKeys(t *testing.T) {
+	k, filename := editScript(t, "alpha\nbravo\ncharlie\n")
+	k.press("E", key.ModShortcut)
+	if !k.ui.edit.open || !k.ui.edit.whole {
+		t.Fatal("Ctrl+E didn't open the editor of the whole script")
+	}
+	k.ui.edit.editor.SetCaret(len("alpha\nbr"), len("alpha\nbr"))
+	k.frame()
+	k.press(key.NameUpArrow, 0)
+	if line, _ := k.ui.edit.editor.CaretPos(); line != 0 || k.p.scrollBy != 0 {
+		t.Errorf("after Up the caret is on line %d, and the text scrolled %v", line, k.p.scrollBy)
+	}
+	k.press(key.NameReturn, 0)
+	k.press(key.NameDownArrow, 0)
+	k.press(key.NamePageDown, 0)
+	if k.p.scrollBy != 0 {
+		t.Errorf("the arrows scrolled the text by %v", k.p.scrollBy)
+	}
+	k.press("S", key.ModShortcut)
+	if k.ui.edit.open {
+		t.Error("Ctrl+S didn't save and close the editor")
+	}
+	if got := readFile(t, filename); got != "al\npha\nbravo\ncharlie\n" {
+		t.Errorf("saved %q", got)
+	}
+	if !strings.HasSuffix(k.p.paragraphList[1].text, "pha") {
+		t.Errorf("the text on screen is %q", k.p.paragraphList[1].text)
+	}
+}
+
+// Saving a paragraph finds its line again if the file changed while editing, and won't overwrite a line that changed
+func TestEditFileChanged(t *testing.T) {
+	k, filename := editScript(t, "alpha\nbravo\ncharlie\n")
+	k.press("E", 0)
+	k.ui.edit.editor.SetText("BRAVO")
+	if err := os.WriteFile(filename, []byte("title\n\nalpha\nbravo\ncharlie\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.ui.edit.save(k.p); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filename); got != "title\n\nalpha\nBRAVO\ncharlie\n" {
+		t.Errorf("with a line added above, saved %q", got)
+	}
+
+	k, filename = editScript(t, "alpha\nbravo\ncharlie\n")
+	k.press("E", 0)
+	k.ui.edit.editor.SetText("BRAVO")
+	if err := os.WriteFile(filename, []byte("alpha\nbravo two\ncharlie\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.ui.edit.save(k.p); err == nil {
+		t.Error("saved over a line that changed in the file")
+	}
+	if got := readFile(t, filename); got != "alpha\nbravo two\ncharlie\n" {
+		t.Errorf("with the line changed, the file is now %q", got)
+	}
+
+	k, filename = editScript(t, "alpha\nbravo\ncharlie\n")
+	k.press("E", key.ModShortcut)
+	if err := os.WriteFile(filename, []byte("alpha\nbravo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.ui.edit.save(k.p); err == nil {
+		t.Error("saved the whole script over a file that changed")
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/unit"
+)
+
+// Recording and replaying a rehearsal.
+// With -record, every frame where the text moved or a key was pressed becomes a line in a timeline file.
+// With -replay, the prompter scrolls exactly as in that timeline, and ignores the keyboard and mouse.
+//
+// Files ending in .jsonl hold one JSON object per line, anything else is CSV:
+//
+//	time,position,autospeed,autoscroll,action
+//	0.000,4,50,false,
+//	2.016,4,50,true,start-stop
+//	2.033,4.02,50,true,
+
+// timelineSample is the state of the prompter at a point in time
+type timelineSample struct {
+	// Seconds since the recording started
+	Time float64 `json:"time"`
+	// The paragraph at the focus bar, and how far into it, like 4.25
+	Position   float32 `json:"position"`
+	Autospeed  float32 `json:"autospeed"`
+	Autoscroll bool    `json:"autoscroll"`
+	// The action that was done in this frame, if any
+	Action string `json:"action,omitempty"`
+}
+
+// The CSV columns, in order
+var timelineColumns = []string{"time", "position", "autospeed", "autoscroll", "action"}
+
+// isJSONL tells if a timeline file is JSON lines rather than CSV
+func isJSONL(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".jsonl"
+}
+
+// ---------- RECORDING ----------
+
+// recorder writes the timeline to a file as we go
+type recorder struct {
+	file  *os.File
+	out   *bufio.Writer
+	csv   *csv.Writer
+	start time.Time
+	// The last sample written, to skip frames where nothing happened
+	last    timelineSample
+	written bool
+	// The last frame skipped. It's written before the next change,
+	// so the replay knows the text stood still until then.
+	skipped    timelineSample
+	hasSkipped bool
+}
+
+// newRecorder creates the timeline file
+func newRecorder(filename string) (*recorder, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := &recorder{file: f, out: bufio.NewWriter(f)}
+	if !isJSONL(filename) {
+		r.csv = csv.NewWriter(r.out)
+		if err := r.csv.Write(timelineColumns); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// record adds a sample, unless nothing changed since the last one.
+// The time of the first sample is time 0.
+func (r *recorder) record(now time.Time, s timelineSample) error {
+	if r == nil {
+		return nil
+	}
+	if r.start.IsZero() {
+		r.start = now
+	}
+	s.Time = now.Sub(r.start).Seconds()
+	last := r.last
+	last.Time = s.Time
+	if r.written && s == last && s.Action == "" {
+		r.skipped, r.hasSkipped = s, true
+		return nil
+	}
+	if r.hasSkipped {
+		r.hasSkipped = false
+		if err := r.write(r.skipped); err != nil {
+			return err
+		}
+	}
+	r.last, r.written = s, true
+	return r.write(s)
+}
+
+// write writes a sample to the file
+func (r *recorder) write(s timelineSample) error {
+	if r.csv == nil {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		_, err = r.out.Write(append(data, '\n'))
+		return err
+	}
+	return r.csv.Write([]string{
+		strconv.FormatFloat(s.Time, 'f', 3, 64),
+		strconv.FormatFloat(float64(s.Position), 'f', -1, 32),
+		strconv.FormatFloat(float64(s.Autospeed), 'f', -1, 32),
+		strconv.FormatBool(s.Autoscroll),
+		s.Action,
+	})
+}
+
+// close writes what's left, and closes the file
+func (r *recorder) close() error {
+	if r == nil {
+		return nil
+	}
+	// The last frame, so the replay runs as long as the rehearsal
+	if r.hasSkipped {
+		r.hasSkipped = false
+		if err := r.write(r.skipped); err != nil {
+			r.file.Close()
+			return err
+		}
+	}
+	if r.csv != nil {
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil {
+			r.file.Close()
+			return err
+		}
+	}
+	if err := r.out.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// ---------- REPLAYING ----------
+
+// timeline is a recorded rehearsal, in order of time
+type timeline []timelineSample
+
+// loadTimeline reads a timeline written by the recorder
+func loadTimeline(filename string) (timeline, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var t timeline
+	if isJSONL(filename) {
+		t, err = readJSONLTimeline(f)
+	} else {
+		t, err = readCSVTimeline(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(t) == 0 {
+		return nil, fmt.Errorf("%s: the timeline is empty", filename)
+	}
+	for i := 1; i < len(t); i++ {
+		if t[i].Time < t[i-1].Time {
+			return nil, fmt.Errorf("%s: the time goes backwards at sample %d", filename, i+1)
+		}
+	}
+	return t, nil
+}
+
+func readJSONLTimeline(r io.Reader) (timeline, error) {
+	var t timeline
+	lines := bufio.NewScanner(r)
+	for n := 1; lines.Scan(); n++ {
+		if strings.TrimSpace(lines.Text()) == "" {
+			continue
+		}
+		var s timelineSample
+		if err := json.Unmarshal(lines.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		t = append(t, s)
+	}
+	return t, lines.Err()
+}
+
+func readCSVTimeline(r io.Reader) (timeline, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(timelineColumns, ",") {
+		return nil, errors.New("the first line must be " + strings.Join(timelineColumns, ","))
+	}
+	var t timeline
+	for n, record := range records[1:] {
+		seconds, err1 := strconv.ParseFloat(record[0], 64)
+		position, err2 := strconv.ParseFloat(record[1], 32)
+		autospeed, err3 := strconv.ParseFloat(record[2], 32)
+		autoscroll, err4 := strconv.ParseBool(record[3])
+		if err := errors.Join(err1, err2, err3, err4); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+2, err)
+		}
+		t = append(t, timelineSample{
+			Time:       seconds,
+			Position:   float32(position),
+			Autospeed:  float32(autospeed),
+			Autoscroll: autoscroll,
+			Action:     record[4],
+		})
+	}
+	return t, nil
+}
+
+// at gives the state at a time since the start.
+// While autoscrolling, the position moves smoothly between samples,
+// so the replay looks the same even if the frames come at other times than when recording.
+// Otherwise the text stays where it was, until the next sample moves it.
+func (t timeline) at(seconds float64) timelineSample {
+	// The last sample at or before the time
+	i := sort.Search(len(t), func(i int) bool { return t[i].Time > seconds }) - 1
+	if i < 0 {
+		return t[0]
+	}
+	s := t[i]
+	if s.Autoscroll && i+1 < len(t) && t[i+1].Time > s.Time {
+		next := t[i+1]
+		f := float32((seconds - s.Time) / (next.Time - s.Time))
+		s.Position = s.Position + (next.Position-s.Position)*f
+	}
+	s.Time = seconds
+	return s
+}
+
+// duration is how long the timeline runs
+func (t timeline) duration() float64 {
+	return t[len(t)-1].Time
+}
+
+// replay plays back a timeline, from the first frame
+type replay struct {
+	timeline timeline
+	start    time.Time
+	// The next sample, to show the actions as they come
+	next int
+	done bool
+}
+
+// apply moves the prompter to where it was at this point of the rehearsal.
+// It returns false when the replay is over.
+func (r *replay) apply(now time.Time, p *prompter) bool {
+	if r.done {
+		return false
+	}
+	if r.start.IsZero() {
+		r.start = now
+	}
+	seconds := now.Sub(r.start).Seconds()
+	for ; r.next < len(r.timeline) && r.timeline[r.next].Time <= seconds; r.next++ {
+		if action := r.timeline[r.next].Action; action != "" {
+			fmt.Printf("REPLAY: %s\n", action)
+		}
+	}
+	s := r.timeline.at(seconds)
+	p.position, p.scrollBy = positionFromValue(s.Position), 0
+	p.autospeed = unit.Dp(s.Autospeed)
+	p.autoscroll = s.Autoscroll
+	if seconds > r.timeline.duration() {
+		fmt.Printf("REPLAY: done\n")
+		r.done = true
+		p.autoscroll = false
+	}
+	return !r.done
+}
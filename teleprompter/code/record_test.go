@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The same rehearsal, as CSV and as JSON lines, reads the same
+func TestLoadTimeline(t *testing.T) {
+	csv, err := loadTimeline("testdata/rehearsal.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonl, err := loadTimeline("testdata/rehearsal.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(csv) != 5 || len(jsonl) != len(csv) {
+		t.Fatalf("read %d and %d samples, want 5 of each", len(csv), len(jsonl))
+	}
+	for i := range csv {
+		if csv[i] != jsonl[i] {
+			t.Errorf("sample %d: %+v from CSV, %+v from JSON lines", i+1, csv[i], jsonl[i])
+		}
+	}
+}
+
+func TestLoadTimelineRejects(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"testdata/bad-header.csv", "the first line must be time,position"},
+		{"testdata/backwards.csv", "the time goes backwards at sample 3"},
+		{"testdata/bad-number.jsonl", "line 2"},
+	}
+	for _, tt := range tests {
+		_, err := loadTimeline(tt.filename)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want one about %q", tt.filename, err, tt.want)
+		}
+	}
+	if _, err := loadTimeline("testdata/missing.csv"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("a missing file: error %v, want one that it doesn't exist", err)
+	}
+}
+
+// While autoscrolling the position moves smoothly between samples, otherwise it stands still
+func TestTimelineAt(t *testing.T) {
+	tl, err := loadTimeline("testdata/rehearsal.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		seconds    float64
+		position   float32
+		autoscroll bool
+	}{
+		{-1, 4, false},
+		{1, 4, false},
+		{2, 4, true},
+		{3, 4.5, true},
+		{3.5, 4.75, true},
+		{4.5, 5.25, true},
+		{6, 5.5, false},
+		{7, 6, false},
+		{100, 6, false},
+	}
+	for _, tt := range tests {
+		s := tl.at(tt.seconds)
+		if !near(s.Position, tt.position) || s.Autoscroll != tt.autoscroll {
+			t.Errorf("at %v: position %v autoscroll %v, want %v and %v", tt.seconds, s.Position, s.Autoscroll, tt.position, tt.autoscroll)
+		}
+	}
+}
+
+// The replay moves the prompter as the timeline says, and stops after the last sample
+func TestReplayApply(t *testing.T) {
+	tl, err := loadTimeline("testdata/rehearsal.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &replay{timeline: tl}
+	p := &prompter{}
+
+	if !r.apply(start, p) || p.autoscroll || p.position != (scrollPosition{index: 4}) {
+		t.Errorf("at the start: position %+v autoscroll %v", p.position, p.autoscroll)
+	}
+	if !r.apply(start.Add(3*time.Second), p) || !p.autoscroll || p.position.index != 4 || !near(p.position.fraction, 0.5) {
+		t.Errorf("after 3 seconds: position %+v autoscroll %v", p.position, p.autoscroll)
+	}
+	if !r.apply(start.Add(7*time.Second), p) || p.position != (scrollPosition{index: 6}) || p.autospeed != 60 {
+		t.Errorf("after 7 seconds: position %+v speed %v", p.position, p.autospeed)
+	}
+	p.autoscroll = true
+	if r.apply(start.Add(8*time.Second), p) || p.autoscroll {
+		t.Errorf("after the end: the replay goes on, autoscroll %v", p.autoscroll)
+	}
+	if r.apply(start.Add(9*time.Second), p) {
+		t.Error("a replay that's done started again")
+	}
+}
+
+// What's recorded is replayed, in both formats
+func TestRecordAndLoad(t *testing.T) {
+	for _, name := range []string{"rehearsal.csv", "rehearsal.jsonl"} {
+		filename := filepath.Join(t.TempDir(), name)
+		r, err := newRecorder(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		frames := []timelineSample{
+			{Position: 2, Autospeed: 50},
+			{Position: 2, Autospeed: 50, Autoscroll: true, Action: "start-stop"},
+			{Position: 2.5, Autospeed: 50, Autoscroll: true},
+			{Position: 2.5, Autospeed: 50},
+			{Position: 2.5, Autospeed: 50},
+			{Position: 2.5, Autospeed: 50},
+		}
+		for i, s := range frames {
+			if err := r.record(start.Add(time.Duration(i)*time.Second), s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := r.close(); err != nil {
+			t.Fatal(err)
+		}
+		tl, err := loadTimeline(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The frames where nothing changed are left out, except the last, so the replay runs as long
+		if len(tl) != 5 || tl.duration() != 5 {
+			t.Errorf("%s: %d samples over %v seconds, want 5 over 5", name, len(tl), tl.duration())
+		}
+		if s := tl.at(2); s.Position != 2.5 || !s.Autoscroll || tl[1].Action != "start-stop" {
+			t.Errorf("%s: read back %+v", name, tl)
+		}
+	}
+}
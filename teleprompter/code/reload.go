@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// Live reload. Writers keep fixing the script while we're on air,
+// so the file is checked for changes and read again when it has changed.
+
+// How often to look for changes in the file
+const watchInterval = time.Second / 2
+
+// scriptReload is the result of reading the file again.
+// If the read failed, err says why, and the old text should be kept.
+type scriptReload struct {
+	// Which script in the playlist
+	script     int
+	paragraphs []paragraph
+	err        error
+}
+
+// A channel to pass new versions of the script to the draw loop
+var scriptReloads chan scriptReload
+
+// watchText checks the file every watchInterval, and when it has changed, reads it
+// and sends the result on scriptReloads. wake is called after every reload,
+// so the draw loop gets to see it. script is where the file is in the playlist.
+func watchText(script int, filename string, wake func()) {
+	last, _ := os.Stat(filename)
+	for {
+		time.Sleep(watchInterval)
+		info, err := os.Stat(filename)
+		if err != nil {
+			// Probably in the middle of being saved. Try again later.
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+
+		paragraphs, err := loadText(filename)
+		if err == nil && info.Size() == 0 {
+			err = errors.New("the file is empty")
+		}
+		scriptReloads <- scriptReload{script: script, paragraphs: paragraphs, err: err}
+		wake()
+	}
+}
+
+// findAnchor finds the paragraph in the new script that matches the one at index in the old.
+// Paragraphs are matched on their text, so it doesn't matter if lines were added or removed above.
+// If the paragraph was deleted, the closest neighbour that survived takes its place, and moved is true.
+func findAnchor(old, new []paragraph, index int) (newIndex int, moved bool) {
+	if len(new) == 0 {
+		return 0, true
+	}
+	if index < 0 || index >= len(old) {
+		return min(max(index, 0), len(new)-1), true
+	}
+	if i := findMatch(new, old[index], index); i >= 0 {
+		return i, false
+	}
+	// Look further and further away, first after and then before
+	for d := 1; d < len(old); d++ {
+		if index+d < len(old) {
+			if i := findMatch(new, old[index+d], index); i >= 0 {
+				return i, true
+			}
+		}
+		if index-d >= 0 {
+			if i := findMatch(new, old[index-d], index); i >= 0 {
+				return i, true
+			}
+		}
+	}
+	return min(index, len(new)-1), true
+}
+
+// findMatch finds the paragraph with the same text as p, closest to near.
+// Blank lines are everywhere, so they never match. It gives -1 if there is no match.
+func findMatch(paragraphs []paragraph, p paragraph, near int) int {
+	if strings.TrimSpace(p.text) == "" {
+		return -1
+	}
+	best := -1
+	for i, candidate := range paragraphs {
+		if candidate.kind != p.kind || candidate.text != p.text {
+			continue
+		}
+		if best < 0 || abs(i-near) < abs(best-near) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Remote control over HTTP, for a floor manager with a tablet.
+//
+//	POST /start, /stop, /toggle        start and stop autoscroll
+//	POST /speed?dps=80 or ?wpm=150     set the speed, in Dp per second or words per minute
+//	POST /jump?paragraph=12            move line 12 of the script to the focus bar
+//	POST /jump?section=Closing         move a section to the focus bar, or 404 if there's none by that name
+//	POST /fontsize?value=40            set the font size
+//	POST /color                        switch to the next color theme
+//	GET  /state                        the current state, as JSON
+//	GET  /ws                           a WebSocket streaming the state as it changes
+//
+// Browsers send where a request comes from, and only pages served from the remote control's own address
+// are let through, so a web page the operator happens to have open can't drive the prompter.
+// Tools like curl send no origin, and are let through as well.
+//
+// The server never touches the prompter directly. Commands are queued on a channel
+// and picked up by the draw loop, which in turn publishes its state for the server to read.
+
+// remoteCommand is a command for the draw loop
+type remoteCommand struct {
+	action string
+	value  float64
+	name   string
+}
+
+// prompterState is what the draw loop publishes after every frame
+type prompterState struct {
+	Autoscroll bool    `json:"autoscroll"`
+	Speed      float32 `json:"speed"`
+	WPMMode    bool    `json:"wpmMode"`
+	WPM        float32 `json:"wpm"`
+	Position   float32 `json:"position"`
+	Paragraph  int     `json:"paragraph"`
+	Paragraphs int     `json:"paragraphs"`
+	Section    string  `json:"section"`
+	FontSize   float32 `json:"fontSize"`
+	Color      string  `json:"color"`
+}
+
+// How often the WebSocket checks for a new state to send
+const streamInterval = time.Second / 10
+
+// remote is the HTTP server side of the remote control
+type remote struct {
+	commands chan remoteCommand
+	// wake makes the draw loop draw a new frame, so it sees new commands
+	wake func()
+
+	mu    sync.Mutex
+	state prompterState
+	// The sections of the script on screen, so a jump to a section that isn't there can be refused.
+	// The draw loop makes a new list when they change, and never changes this one.
+	sections []section
+}
+
+// newRemote creates a remote control. wake is called every time a command is queued.
+func newRemote(wake func()) *remote {
+	return &remote{
+		commands: make(chan remoteCommand, 16),
+		wake:     wake,
+	}
+}
+
+// pending returns the commands queued since last time, without waiting for more
+func (r *remote) pending() []remoteCommand {
+	if r == nil {
+		return nil
+	}
+	cmds := []remoteCommand{}
+	for {
+		select {
+		case cmd := <-r.commands:
+			cmds = append(cmds, cmd)
+		default:
+			return cmds
+		}
+	}
+}
+
+// publish stores the state of the prompter and its sections, for the server to read
+func (r *remote) publish(state prompterState, sections []section) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.state, r.sections = state, sections
+	r.mu.Unlock()
+}
+
+// snapshot is the last state published
+func (r *remote) snapshot() prompterState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// handler routes the requests
+func (r *remote) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /start", r.simple("start"))
+	mux.HandleFunc("POST /stop", r.simple("stop"))
+	mux.HandleFunc("POST /toggle", r.simple("toggle"))
+	mux.HandleFunc("POST /color", r.simple("color"))
+	mux.HandleFunc("POST /speed", r.speed)
+	mux.HandleFunc("POST /jump", r.jump)
+	mux.HandleFunc("POST /fontsize", r.fontSize)
+	mux.HandleFunc("GET /state", r.getState)
+	mux.Handle("GET /ws", websocket.Server{Handler: r.stream})
+	return sameOrigin(mux)
+}
+
+// allowedOrigin tells if a request comes from a page of the remote control itself, or from no page at all
+func allowedOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == req.Host
+}
+
+// sameOrigin refuses requests from pages elsewhere
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !allowedOrigin(req) {
+			http.Error(w, "requests from other sites are not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// send queues a command for the draw loop
+func (r *remote) send(w http.ResponseWriter, cmd remoteCommand) {
+	select {
+	case r.commands <- cmd:
+		if r.wake != nil {
+			r.wake()
+		}
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "too many commands, try again", http.StatusServiceUnavailable)
+	}
+}
+
+// simple handles the commands without parameters
+func (r *remote) simple(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r.send(w, remoteCommand{action: action})
+	}
+}
+
+func (r *remote) speed(w http.ResponseWriter, req *http.Request) {
+	for _, unit := range []string{"dps", "wpm"} {
+		if s := req.FormValue(unit); s != "" {
+			value, err := strconv.ParseFloat(s, 32)
+			if err != nil || value < 0 {
+				http.Error(w, unit+" must be a number, 0 or more", http.StatusBadRequest)
+				return
+			}
+			r.send(w, remoteCommand{action: "speed", name: unit, value: value})
+			return
+		}
+	}
+	http.Error(w, "give the speed as dps or wpm", http.StatusBadRequest)
+}
+
+func (r *remote) jump(w http.ResponseWriter, req *http.Request) {
+	if s := req.FormValue("paragraph"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "paragraph must be a line number, from 1", http.StatusBadRequest)
+			return
+		}
+		r.send(w, remoteCommand{action: "paragraph", value: float64(n)})
+		return
+	}
+	if s := req.FormValue("section"); s != "" {
+		r.mu.Lock()
+		found := findSection(r.sections, s) >= 0
+		r.mu.Unlock()
+		if !found {
+			http.Error(w, "no section called "+strconv.Quote(s), http.StatusNotFound)
+			return
+		}
+		r.send(w, remoteCommand{action: "section", name: s})
+		return
+	}
+	http.Error(w, "give a paragraph or a section to jump to", http.StatusBadRequest)
+}
+
+func (r *remote) fontSize(w http.ResponseWriter, req *http.Request) {
+	value, err := strconv.ParseFloat(req.FormValue("value"), 32)
+	if err != nil || value <= 0 {
+		http.Error(w, "value must be a font size above 0", http.StatusBadRequest)
+		return
+	}
+	r.send(w, remoteCommand{action: "fontsize", value: value})
+}
+
+func (r *remote) getState(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(r.snapshot())
+}
+
+// stream sends the state over a WebSocket every time it changes
+func (r *remote) stream(ws *websocket.Conn) {
+	defer ws.Close()
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+
+	// We never expect anything from the client, but reading tells us when it leaves
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, ws)
+		close(done)
+	}()
+
+	var last prompterState
+	first := true
+	for {
+		state := r.snapshot()
+		if first || state != last {
+			if err := websocket.JSON.Send(ws, state); err != nil {
+				return
+			}
+			last, first = state, false
+		}
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
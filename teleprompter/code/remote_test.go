@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// post sends a request to the remote control, and returns the response
+func post(t *testing.T, r *remote, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.handler().ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRemoteStatus(t *testing.T) {
+	r := newRemote(nil)
+	r.publish(prompterState{}, []section{{title: "Opening", index: 0}, {title: "Closing", index: 12}})
+	tests := []struct {
+		method, target string
+		want           int
+	}{
+		{"POST", "/start", http.StatusAccepted},
+		{"POST", "/speed?dps=80", http.StatusAccepted},
+		{"POST", "/speed?wpm=150", http.StatusAccepted},
+		{"POST", "/speed?wpm=fast", http.StatusBadRequest},
+		{"POST", "/speed?dps=-1", http.StatusBadRequest},
+		{"POST", "/speed", http.StatusBadRequest},
+		{"POST", "/jump?paragraph=12", http.StatusAccepted},
+		{"POST", "/jump?paragraph=0", http.StatusBadRequest},
+		{"POST", "/jump?section=closing", http.StatusAccepted},
+		{"POST", "/jump?section=Nope", http.StatusNotFound},
+		{"POST", "/jump", http.StatusBadRequest},
+		{"POST", "/fontsize?value=40", http.StatusAccepted},
+		{"POST", "/fontsize?value=0", http.StatusBadRequest},
+		{"GET", "/start", http.StatusMethodNotAllowed},
+		{"GET", "/state", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := post(t, r, tt.method, tt.target).Code; got != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, got, tt.want)
+		}
+		r.pending()
+	}
+}
+
+// Accepted commands are queued for the draw loop, and refused ones are not
+func TestRemotePending(t *testing.T) {
+	woken := 0
+	r := newRemote(func() { woken++ })
+	r.publish(prompterState{}, []section{{title: "Closing", index: 12}})
+	post(t, r, "POST", "/toggle")
+	post(t, r, "POST", "/jump?section=Nope")
+	post(t, r, "POST", "/speed?wpm=oops")
+	post(t, r, "POST", "/jump?section=Closing")
+	post(t, r, "POST", "/speed?wpm=120")
+
+	want := []remoteCommand{
+		{action: "toggle"},
+		{action: "section", name: "Closing"},
+		{action: "speed", name: "wpm", value: 120},
+	}
+	got := r.pending()
+	if len(got) != len(want) {
+		t.Fatalf("pending = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("command %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if woken != len(want) {
+		t.Errorf("the draw loop was woken %d times, want %d", woken, len(want))
+	}
+	if more := r.pending(); len(more) != 0 {
+		t.Errorf("pending again = %+v, want nothing", more)
+	}
+}
+
+// When the queue is full, commands are refused rather than waiting
+func TestRemoteFull(t *testing.T) {
+	r := newRemote(nil)
+	for range cap(r.commands) {
+		post(t, r, "POST", "/start")
+	}
+	if got := post(t, r, "POST", "/start").Code; got != http.StatusServiceUnavailable {
+		t.Errorf("status %d with a full queue, want %d", got, http.StatusServiceUnavailable)
+	}
+}
+
+func TestRemoteState(t *testing.T) {
+	r := newRemote(nil)
+	r.publish(prompterState{Autoscroll: true, Paragraph: 3, Section: "Closing"}, nil)
+	w := post(t, r, "GET", "/state")
+	var state prompterState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state != r.snapshot() {
+		t.Errorf("state = %+v, want %+v", state, r.snapshot())
+	}
+}
+
+// Pages from other sites may not send commands, but the remote control's own pages and tools without a page may
+func TestRemoteOrigin(t *testing.T) {
+	r := newRemote(nil)
+	tests := []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusAccepted},
+		{"http://example.com", http.StatusAccepted},
+		{"http://evil.example", http.StatusForbidden},
+		{"http://example.com.evil.example", http.StatusForbidden},
+		{"not a url %", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/start", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		r.handler().ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("origin %q: status %d, want %d", tt.origin, w.Code, tt.want)
+		}
+	}
+	if got := r.pending(); len(got) != 2 {
+		t.Errorf("pending = %+v, want the two accepted commands", got)
+	}
+}
+
+// The WebSocket sends the state when it connects, and again when it changes
+func TestRemoteStream(t *testing.T) {
+	r := newRemote(nil)
+	r.publish(prompterState{Paragraph: 1}, nil)
+	server := httptest.NewServer(r.handler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var state prompterState
+	if err := websocket.JSON.Receive(ws, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Paragraph != 1 {
+		t.Errorf("first state = %+v, want paragraph 1", state)
+	}
+	r.publish(prompterState{Paragraph: 2, Autoscroll: true}, nil)
+	if err := websocket.JSON.Receive(ws, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Paragraph != 2 || !state.Autoscroll {
+		t.Errorf("next state = %+v, want paragraph 2 and autoscroll", state)
+	}
+
+	// A page from another site can't listen in
+	if ws, err := websocket.Dial(wsURL, "", "http://evil.example"); err == nil {
+		ws.Close()
+		t.Error("a WebSocket from another site was let in")
+	}
+}
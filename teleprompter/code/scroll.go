@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"gioui.org/unit"
+)
+
+// Autoscroll speed is measured in Dp per second, not per frame.
+// That way the reading pace is the same on a slow laptop and a fast monitor.
+const (
+	// The speed we start at, roughly a calm reading pace at the default font size
+	defaultSpeed unit.Dp = 50
+	// One press on F or S changes the speed this much. Shift gives five steps.
+	speedStep unit.Dp = 10
+	// If frames stop for longer than this, the window was probably hidden or the
+	// machine suspended. We don't want the text to jump ahead when it comes back.
+	maxFrameGap = time.Second / 4
+)
+
+// scrollDistance returns how far the text should move between two frames,
+// given the speed in Dp per second and the timestamps of the frames.
+// A zero previous timestamp means this is the first frame, which moves nothing.
+func scrollDistance(speed unit.Dp, prev, now time.Time) unit.Dp {
+	if prev.IsZero() || !now.After(prev) || speed <= 0 {
+		return 0
+	}
+	elapsed := now.Sub(prev)
+	if elapsed > maxFrameGap {
+		elapsed = maxFrameGap
+	}
+	return speed * unit.Dp(elapsed.Seconds())
+}
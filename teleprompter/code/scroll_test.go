@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gioui.org/unit"
+)
+
+func TestScrollDistance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		speed     unit.Dp
+		prev, now time.Time
+		want      unit.Dp
+	}{
+		{"first frame", 50, time.Time{}, start, 0},
+		{"same time", 50, start, start, 0},
+		{"time going backwards", 50, start, start.Add(-time.Second), 0},
+		{"no speed", 0, start, start.Add(time.Second / 10), 0},
+		{"a tenth of a second", 50, start, start.Add(time.Second / 10), 5},
+		{"a long gap is cut short", 50, start, start.Add(10 * time.Second), 50 * unit.Dp(maxFrameGap.Seconds())},
+		{"just at the longest gap", 100, start, start.Add(maxFrameGap), 100 * unit.Dp(maxFrameGap.Seconds())},
+	}
+	for _, tt := range tests {
+		got := scrollDistance(tt.speed, tt.prev, tt.now)
+		if !near(float32(got), float32(tt.want)) {
+			t.Errorf("%s: scrollDistance = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// One second of frames moves the text as far at 30 frames a second as at 144
+func TestScrollDistanceFrameRate(t *testing.T) {
+	for _, fps := range []int{30, 144} {
+		start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		prev := time.Time{}
+		var total unit.Dp
+		for frame := 0; frame <= fps; frame++ {
+			now := start.Add(time.Duration(frame) * time.Second / time.Duration(fps))
+			total += scrollDistance(defaultSpeed, prev, now)
+			prev = now
+		}
+		if !near(float32(total), float32(defaultSpeed)) {
+			t.Errorf("%d fps: moved %v in a second, want %v", fps, total, defaultSpeed)
+		}
+	}
+}
+
+// near compares floats, allowing for rounding
+func near(a, b float32) bool {
+	d := a - b
+	return d < 0.01 && d > -0.01
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Search.
+// Press / and type to find a paragraph. The text moves to the first match as you type.
+// Enter closes the search box and keeps the matches, so n and N (Shift+N) move between them,
+// and so do Ctrl+N and Ctrl+P. Esc in the search box goes back to where we were.
+// Esc after that forgets the matches, and n narrows the text again.
+// Upper and lower case are the same, and so are letters with and without accents, so cafe finds Café.
+
+// Colors to mark matches with. The selected match is marked more strongly.
+var (
+	matchColor    = color.NRGBA{R: 0xff, G: 0xc0, B: 0x00, A: 0x30}
+	selectedColor = color.NRGBA{R: 0xff, G: 0xc0, B: 0x00, A: 0x70}
+)
+
+// Letters that aren't a plain letter with an accent, so they must be spelled out
+var plainLetters = strings.NewReplacer("ø", "o", "æ", "ae", "œ", "oe", "ß", "ss", "ł", "l", "đ", "d", "ı", "i")
+
+// foldText makes text ready for comparing, in lower case and without accents
+func foldText(s string) string {
+	// A transformer keeps state, so each call needs its own
+	noAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(noAccents, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return plainLetters.Replace(folded)
+}
+
+// findMatches lists the paragraphs containing the query
+func findMatches(paragraphs []paragraph, query string) []int {
+	query = foldText(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	matches := []int{}
+	for i, p := range paragraphs {
+		if strings.Contains(foldText(p.text), query) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// searchResult is what was searched for, and found. It's shared by all windows.
+type searchResult struct {
+	query    string
+	matches  []int
+	selected int
+}
+
+// highlight is the color to mark a paragraph with, if it's a match
+func (s *searchResult) highlight(index int) (color.NRGBA, bool) {
+	for i, m := range s.matches {
+		if m == index {
+			if i == s.selected {
+				return selectedColor, true
+			}
+			return matchColor, true
+		}
+	}
+	return color.NRGBA{}, false
+}
+
+// searchFor finds the query, and moves the first match from the focus bar and down to the focus bar
+func (p *prompter) searchFor(query string) {
+	p.search = searchResult{query: query, matches: findMatches(p.paragraphList, query)}
+	if len(p.search.matches) == 0 {
+		return
+	}
+	current := p.focus()
+	for i, m := range p.search.matches {
+		if m >= current {
+			p.search.selected = i
+			break
+		}
+	}
+	p.jumpTo = p.search.matches[p.search.selected]
+}
+
+// nextMatch moves to the next match, or the previous with a step of -1, round and round
+func (p *prompter) nextMatch(step int) {
+	n := len(p.search.matches)
+	if n == 0 {
+		return
+	}
+	p.search.selected = ((p.search.selected+step)%n + n) % n
+	p.jumpTo = p.search.matches[p.search.selected]
+}
+
+// searchBox is the search overlay in a window
+type searchBox struct {
+	open   bool
+	editor widget.Editor
+	// Where the text was when the search started, to go back to on Esc
+	origin scrollPosition
+}
+
+// show opens the search box, empty
+func (s *searchBox) show(p *prompter) {
+	s.open = true
+	s.origin = p.position
+	s.editor.SingleLine = true
+	s.editor.Submit = true
+	s.editor.SetText("")
+	p.search = searchResult{}
+}
+
+// cancel closes the search box, and puts the text back where it was
+func (s *searchBox) cancel(gtx C, p *prompter) {
+	s.open = false
+	p.search = searchResult{}
+	p.jumpTo = -1
+	p.moveTo(s.origin)
+	gtx.Execute(key.FocusCmd{})
+}
+
+// update reads what was typed in the search box. It returns true if anything changed.
+func (s *searchBox) update(gtx C, p *prompter) bool {
+	if !s.open {
+		return false
+	}
+	changed := false
+	for {
+		ev, ok := s.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		switch ev.(type) {
+		case widget.ChangeEvent:
+			p.searchFor(s.editor.Text())
+		case widget.SubmitEvent:
+			// Enter keeps the matches, and goes to the selected one
+			s.open = false
+			p.nextMatch(0)
+			gtx.Execute(key.FocusCmd{})
+			fmt.Printf("SEARCH: %q, %d matches\n", p.search.query, len(p.search.matches))
+		}
+		changed = true
+	}
+	return changed
+}
+
+// layout draws the search box at the top of the window
+func (s *searchBox) layout(gtx C, th *material.Theme, colors colorMode, result searchResult) D {
+	// Typing goes to the search box
+	if !gtx.Focused(&s.editor) {
+		gtx.Execute(key.FocusCmd{Tag: &s.editor})
+	}
+	// Keep clicks on the search box from reaching the text below
+	for {
+		_, ok := gtx.Event(pointer.Filter{Target: s, Kinds: pointer.Press})
+		if !ok {
+			break
+		}
+	}
+
+	found := ""
+	switch {
+	case result.query == "":
+	case len(result.matches) == 0:
+		found = "no matches"
+	default:
+		found = fmt.Sprintf("%d of %d", result.selected+1, len(result.matches))
+	}
+
+	width := min(gtx.Constraints.Max.X, gtx.Dp(400))
+	defer op.Offset(image.Pt((gtx.Constraints.Max.X-width)/2, gtx.Dp(8))).Push(gtx.Ops).Pop()
+	gtx.Constraints = layout.Exact(image.Pt(width, gtx.Dp(44)))
+	defer clip.UniformRRect(image.Rectangle{Max: gtx.Constraints.Max}, gtx.Dp(8)).Push(gtx.Ops).Pop()
+	paint.Fill(gtx.Ops, colors.background)
+	event.Op(gtx.Ops, s)
+
+	return layout.UniformInset(unit.Dp(10)).Layout(gtx, func(gtx C) D {
+		label := func(text string) layout.Widget {
+			l := material.Body1(th, text)
+			l.Color = colors.foreground
+			return l.Layout
+		}
+		editor := material.Editor(th, &s.editor, "")
+		editor.Color = colors.foreground
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(label("/ ")),
+			layout.Flexed(1, editor.Layout),
+			layout.Rigid(label(found)),
+		)
+	})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"image"
+	"regexp"
+	"strings"
+
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// section is a named part of the script, like "Q&A" or "Closing"
+type section struct {
+	title string
+	// The paragraph where the section starts
+	index int
+}
+
+// findSections lists the sections of the script.
+// With a pattern, every paragraph matching it starts a section.
+// Without one, the headings do.
+func findSections(paragraphs []paragraph, pattern *regexp.Regexp) []section {
+	sections := []section{}
+	for i, p := range paragraphs {
+		if p.kind == noteParagraph {
+			continue
+		}
+		isSection := p.kind == headingParagraph
+		if pattern != nil {
+			isSection = p.text != "" && pattern.MatchString(p.text)
+		}
+		if isSection {
+			sections = append(sections, section{title: p.text, index: i})
+		}
+	}
+	return sections
+}
+
+// nextSection finds the first section starting after the given paragraph, or -1 if there is none
+func nextSection(sections []section, current int) int {
+	for _, s := range sections {
+		if s.index > current {
+			return s.index
+		}
+	}
+	return -1
+}
+
+// previousSection finds the last section starting before the given paragraph, or -1 if there is none
+func previousSection(sections []section, current int) int {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i].index < current {
+			return sections[i].index
+		}
+	}
+	return -1
+}
+
+// sectionAt gives the title of the section a paragraph belongs to, or "" before the first section
+func sectionAt(sections []section, current int) string {
+	title := ""
+	for _, s := range sections {
+		if s.index <= current {
+			title = s.title
+		}
+	}
+	return title
+}
+
+// findSection finds a section by its title, ignoring case. It gives -1 if there is no such section.
+func findSection(sections []section, title string) int {
+	for _, s := range sections {
+		if strings.EqualFold(strings.TrimSpace(s.title), strings.TrimSpace(title)) {
+			return s.index
+		}
+	}
+	return -1
+}
+
+// sectionMenu is an overlay listing all sections, where the operator can pick one
+type sectionMenu struct {
+	open     bool
+	selected int
+	list     widget.List
+	rows     []widget.Clickable
+}
+
+// show opens the menu, with the section we're in selected
+func (m *sectionMenu) show(sections []section, current int) {
+	m.open = true
+	m.selected = 0
+	for i, s := range sections {
+		if s.index <= current {
+			m.selected = i
+		}
+	}
+	m.list.ScrollTo(m.selected)
+}
+
+// move changes the selected section up or down
+func (m *sectionMenu) move(sections []section, step int) {
+	if len(sections) == 0 {
+		return
+	}
+	m.selected = min(max(m.selected+step, 0), len(sections)-1)
+	m.list.ScrollTo(m.selected)
+}
+
+// layout draws the menu in the middle of the window.
+// If a section was clicked, its paragraph is returned, otherwise -1.
+func (m *sectionMenu) layout(gtx C, th *material.Theme, colors colorMode, sections []section) int {
+	picked := -1
+	if len(m.rows) != len(sections) {
+		m.rows = make([]widget.Clickable, len(sections))
+	}
+	for i := range m.rows {
+		if m.rows[i].Clicked(gtx) {
+			m.selected = i
+			picked = sections[i].index
+		}
+	}
+
+	// Keep clicks on the menu from reaching the text below
+	for {
+		_, ok := gtx.Event(pointer.Filter{Target: m, Kinds: pointer.Press})
+		if !ok {
+			break
+		}
+	}
+
+	// The menu uses at most half the window
+	size := image.Pt(gtx.Constraints.Max.X/2, gtx.Constraints.Max.Y/2)
+	defer op.Offset(image.Pt((gtx.Constraints.Max.X-size.X)/2, (gtx.Constraints.Max.Y-size.Y)/2)).Push(gtx.Ops).Pop()
+	defer clip.UniformRRect(image.Rectangle{Max: size}, gtx.Dp(8)).Push(gtx.Ops).Pop()
+	paint.Fill(gtx.Ops, colors.background)
+	event.Op(gtx.Ops, m)
+	gtx.Constraints = layout.Exact(size)
+
+	m.list.Axis = layout.Vertical
+	layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx C) D {
+		if len(sections) == 0 {
+			empty := material.Body1(th, "No sections found")
+			empty.Color = colors.foreground
+			return empty.Layout(gtx)
+		}
+		return material.List(th, &m.list).Layout(gtx, len(sections), func(gtx C, i int) D {
+			return m.rows[i].Layout(gtx, func(gtx C) D {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+				row := material.Body1(th, sections[i].title)
+				row.Color = colors.foreground
+				row.MaxLines = 1
+				return layout.Background{}.Layout(gtx,
+					func(gtx C) D {
+						// The selected row is marked with the focus bar color
+						if i == m.selected {
+							paint.FillShape(gtx.Ops, colors.focusbar, clip.Rect{Max: gtx.Constraints.Min}.Op())
+						}
+						return D{Size: gtx.Constraints.Min}
+					},
+					func(gtx C) D {
+						return layout.UniformInset(unit.Dp(6)).Layout(gtx, row.Layout)
+					},
+				)
+			})
+		})
+	})
+	return picked
+}
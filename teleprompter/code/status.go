@@ -0,0 +1,35 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// layoutStatus draws a small, dimmed status line in the bottom right corner.
+// Empty parts are skipped, the rest are separated by a dot.
+func layoutStatus(gtx C, th *material.Theme, fg color.NRGBA, parts ...string) D {
+	shown := []string{}
+	for _, p := range parts {
+		if p != "" {
+			shown = append(shown, p)
+		}
+	}
+	// Dim the text, so it doesn't steal attention from the speech
+	fg.A = fg.A / 2
+
+	status := material.Label(th, unit.Sp(14), strings.Join(shown, "  ·  "))
+	status.Color = fg
+	status.Alignment = text.End
+	status.MaxLines = 1
+	return layout.S.Layout(gtx, func(gtx C) D {
+		return layout.UniformInset(unit.Dp(8)).Layout(gtx, func(gtx C) D {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return status.Layout(gtx)
+		})
+	})
+}
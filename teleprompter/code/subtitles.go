@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subtitles.
+// A .srt or .vtt file gives a caption per paragraph, each with the time it should be read.
+// Instead of a fixed speed, autoscroll then brings each caption to the focus bar at its start time.
+// The subtitle clock only runs while autoscrolling, and when the text is moved by hand
+// while paused, the clock follows, so the show can be started from anywhere.
+
+// caption is one timed piece of text from a subtitle file
+type caption struct {
+	start, end time.Duration
+	text       string
+}
+
+// isSubtitles tells if a file should be read as subtitles
+func isSubtitles(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".srt", ".vtt":
+		return true
+	}
+	return false
+}
+
+// Timestamps look like 01:02:03,456 in SRT and 01:02:03.456 or 02:03.456 in WebVTT
+var timestampPattern = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2})[,.](\d{1,3})$`)
+
+// Formatting in the captions, like <i>, <v Speaker> and {\an8}, which the talent shouldn't see
+var captionTagPattern = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// Captions are separated by blank lines
+var blankLinePattern = regexp.MustCompile(`\n[ \t]*\n`)
+
+// parseTimestamp reads a single timestamp
+func parseTimestamp(s string) (time.Duration, error) {
+	m := timestampPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a timestamp", s)
+	}
+	hours, _ := strconv.Atoi("0" + m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.Atoi(m[3])
+	// Fractions are milliseconds, but some files have fewer digits, like 1.5 for 1.500
+	millis, _ := strconv.Atoi(m[4] + strings.Repeat("0", 3-len(m[4])))
+	if minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("%q is not a timestamp", s)
+	}
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(millis)*time.Millisecond, nil
+}
+
+// parseTiming reads a line like 00:00:01,000 --> 00:00:04,000, with or without WebVTT settings after it
+func parseTiming(line string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, errors.New("no --> in the timing")
+	}
+	to = strings.TrimSpace(to)
+	if fields := strings.Fields(to); len(fields) > 0 {
+		to = fields[0]
+	}
+	if start, err = parseTimestamp(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimestamp(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseSubtitles reads the captions of an SRT or WebVTT file.
+// Captions that can't be read are skipped, and each of them gives a warning.
+// The captions come back in order of start time.
+func parseSubtitles(data string) (captions []caption, warnings []error) {
+	data = strings.TrimPrefix(data, "\ufeff")
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\r", "\n")
+
+	blocks := blankLinePattern.Split(strings.TrimSpace(data), -1)
+	for n, block := range blocks {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if lines[0] == "" {
+			continue
+		}
+		// WebVTT headers, comments and styles have no text for the talent
+		first := strings.Fields(lines[0])
+		if len(first) > 0 && (first[0] == "WEBVTT" || first[0] == "NOTE" || first[0] == "STYLE" || first[0] == "REGION") {
+			continue
+		}
+
+		// The timing is on the first line, or on the second after a number or a name
+		timing := -1
+		for i := 0; i < min(len(lines), 2); i++ {
+			if strings.Contains(lines[i], "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			warnings = append(warnings, fmt.Errorf("caption %d has no timing, skipped", n+1))
+			continue
+		}
+		start, end, err := parseTiming(lines[timing])
+		if err != nil {
+			warnings = append(warnings, fmt.Errorf("caption %d: %w, skipped", n+1, err))
+			continue
+		}
+		if end < start {
+			warnings = append(warnings, fmt.Errorf("caption %d ends before it starts", n+1))
+			end = start
+		}
+
+		// The rest is the text, which becomes a single paragraph
+		words := []string{}
+		for _, line := range lines[timing+1:] {
+			line = html.UnescapeString(captionTagPattern.ReplaceAllString(line, ""))
+			words = append(words, strings.Fields(line)...)
+		}
+		if len(words) == 0 {
+			warnings = append(warnings, fmt.Errorf("caption %d has no text, skipped", n+1))
+			continue
+		}
+		captions = append(captions, caption{start: start, end: end, text: strings.Join(words, " ")})
+	}
+
+	sort.SliceStable(captions, func(i, j int) bool { return captions[i].start < captions[j].start })
+	return captions, warnings
+}
+
+// loadSubtitles reads a subtitle file into paragraphs, one per caption
+func loadSubtitles(data string) ([]paragraph, error) {
+	captions, warnings := parseSubtitles(data)
+	for _, w := range warnings {
+		fmt.Printf("SUBS  : %v\n", w)
+	}
+	if len(captions) == 0 {
+		return nil, errors.New("no captions found")
+	}
+	paragraphs := make([]paragraph, len(captions))
+	for i := range captions {
+		paragraphs[i] = paragraph{kind: plainParagraph, text: captions[i].text, caption: &captions[i]}
+	}
+	return paragraphs, nil
+}
+
+// hasCaptions tells if the script came from subtitles
+func (p *prompter) hasCaptions() bool {
+	return len(p.paragraphList) > 0 && p.paragraphList[0].caption != nil
+}
+
+// captionSpeed is the speed, in pixels per second, that brings the next caption to the top of the focus bar
+// at its start time. It also moves the subtitle clock on, and should be called every autoscrolled frame.
+// If the next caption isn't on screen, ok is false, and the speed should stay as it is.
+func (p *prompter) captionSpeed(now time.Time, barTop int) (pxPerSecond float32, ok bool) {
+	if !p.captionFrame.IsZero() {
+		p.captionClock += min(now.Sub(p.captionFrame), maxFrameGap)
+	}
+	p.captionFrame = now
+
+	// The next caption to start
+	next := -1
+	for i, para := range p.paragraphList {
+		if para.caption != nil && para.caption.start > p.captionClock {
+			next = i
+			break
+		}
+	}
+	if next < 0 {
+		return 0, false
+	}
+	for _, pos := range p.onScreen {
+		if pos.index != next {
+			continue
+		}
+		distance := pos.top - barTop
+		if distance <= 0 {
+			// Ahead of time, so wait for the clock
+			return 0, true
+		}
+		left := max((p.paragraphList[next].caption.start - p.captionClock).Seconds(), 0.05)
+		return float32(distance) / float32(left), true
+	}
+	return 0, false
+}
+
+// seekCaptions sets the subtitle clock from where the text is, after it was moved while paused.
+// captionAt is where autoscroll left the text, so we can tell if it has been moved since.
+func (p *prompter) seekCaptions(barTop int) {
+	p.captionFrame = time.Time{}
+	if p.position == p.captionAt {
+		return
+	}
+	p.captionAt = p.position
+	for _, pos := range p.onScreen {
+		if pos.height <= 0 || barTop < pos.top || barTop >= pos.top+pos.height {
+			continue
+		}
+		c := p.paragraphList[pos.index].caption
+		if c == nil {
+			return
+		}
+		// The clock is as far into the caption as the focus bar is
+		end := c.end
+		if pos.index+1 < len(p.paragraphList) && p.paragraphList[pos.index+1].caption != nil {
+			end = p.paragraphList[pos.index+1].caption.start
+		}
+		fraction := float64(barTop-pos.top) / float64(pos.height)
+		p.captionClock = c.start + time.Duration(fraction*float64(end-c.start))
+		return
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"00:00:01,000", time.Second, true},
+		{"01:02:03,456", time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond, true},
+		{"01:02:03.456", time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond, true},
+		{"02:03.456", 2*time.Minute + 3*time.Second + 456*time.Millisecond, true},
+		{"00:01.5", time.Second + 500*time.Millisecond, true},
+		{" 00:00:02,000 ", 2 * time.Second, true},
+		{"00:60:00,000", 0, false},
+		{"00:00:61,000", 0, false},
+		{"1.5", 0, false},
+		{"00:00:01", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTimestamp(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseTimestamp(%q) = %v, %v, want %v and ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseTiming(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end time.Duration
+		err        string
+	}{
+		{"00:00:01,000 --> 00:00:04,000", time.Second, 4 * time.Second, ""},
+		{"00:01.000 --> 00:04.000 align:start position:10%", time.Second, 4 * time.Second, ""},
+		{"00:00:01,000 00:00:04,000", 0, 0, "no -->"},
+		{"00:00:01,000 --> soon", 0, 0, "not a timestamp"},
+	}
+	for _, tt := range tests {
+		start, end, err := parseTiming(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseTiming(%q): error %v, want one about %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || start != tt.start || end != tt.end {
+			t.Errorf("parseTiming(%q) = %v, %v, %v, want %v and %v", tt.in, start, end, err, tt.start, tt.end)
+		}
+	}
+}
+
+// texts lists the text of the captions
+func texts(captions []caption) []string {
+	t := make([]string, len(captions))
+	for i, c := range captions {
+		t[i] = c.text
+	}
+	return t
+}
+
+func TestParseSubtitles(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     []caption
+		warnings int
+	}{
+		{
+			name: "SRT",
+			data: "1\n00:00:01,000 --> 00:00:04,000\nGood evening,\nand welcome.\n\n2\n00:00:05,000 --> 00:00:07,500\nTonight's news.\n",
+			want: []caption{
+				{start: time.Second, end: 4 * time.Second, text: "Good evening, and welcome."},
+				{start: 5 * time.Second, end: 7500 * time.Millisecond, text: "Tonight's news."},
+			},
+		},
+		{
+			name: "WebVTT without hours",
+			data: "WEBVTT\n\nNOTE written by hand\n\nintro\n00:01.000 --> 00:04.000 line:0\nGood evening.\n\n00:05.000 --> 00:07.000\nTonight's news.\n",
+			want: []caption{
+				{start: time.Second, end: 4 * time.Second, text: "Good evening."},
+				{start: 5 * time.Second, end: 7 * time.Second, text: "Tonight's news."},
+			},
+		},
+		{
+			name: "byte order mark and Windows line endings",
+			data: "\ufeff1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nWorld\r\n",
+			want: []caption{
+				{start: time.Second, end: 2 * time.Second, text: "Hello"},
+				{start: 3 * time.Second, end: 4 * time.Second, text: "World"},
+			},
+		},
+		{
+			name: "missing arrow",
+			data: "1\n00:00:01,000 00:00:02,000\nLost\n\n2\n00:00:03,000 --> 00:00:04,000\nFound\n",
+			want: []caption{
+				{start: 3 * time.Second, end: 4 * time.Second, text: "Found"},
+			},
+			warnings: 1,
+		},
+		{
+			name: "ends before it starts",
+			data: "1\n00:00:05,000 --> 00:00:02,000\nBackwards\n",
+			want: []caption{
+				{start: 5 * time.Second, end: 5 * time.Second, text: "Backwards"},
+			},
+			warnings: 1,
+		},
+		{
+			name: "tags are taken out",
+			data: "1\n00:00:01,000 --> 00:00:02,000\n{\\an8}<i>Quietly</i> <v Anna>now &amp; then</v>\n\n2\n00:00:03,000 --> 00:00:04,000\n<b></b>\n",
+			want: []caption{
+				{start: time.Second, end: 2 * time.Second, text: "Quietly now & then"},
+			},
+			warnings: 1,
+		},
+		{
+			name: "out of order",
+			data: "1\n00:00:09,000 --> 00:00:10,000\nLast\n\n2\n00:00:01,000 --> 00:00:02,000\nFirst\n\n3\n00:00:05,000 --> 00:00:06,000\nMiddle\n",
+			want: []caption{
+				{start: time.Second, end: 2 * time.Second, text: "First"},
+				{start: 5 * time.Second, end: 6 * time.Second, text: "Middle"},
+				{start: 9 * time.Second, end: 10 * time.Second, text: "Last"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		captions, warnings := parseSubtitles(tt.data)
+		if len(captions) != len(tt.want) {
+			t.Errorf("%s: captions %q, want %q", tt.name, texts(captions), texts(tt.want))
+			continue
+		}
+		for i := range tt.want {
+			if captions[i] != tt.want[i] {
+				t.Errorf("%s: caption %d = %+v, want %+v", tt.name, i+1, captions[i], tt.want[i])
+			}
+		}
+		if len(warnings) != tt.warnings {
+			t.Errorf("%s: warnings %v, want %d", tt.name, warnings, tt.warnings)
+		}
+	}
+}
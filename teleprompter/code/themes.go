@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Color themes.
+// Besides dark and light, there's a high contrast theme and a green one for night shoots.
+// More themes, like the colors of a brand, go in the preferences file:
+//
+//	"themes": [
+//	  {"name": "brand", "background": "#002b5c", "foreground": "#ffffff", "focusbar": "#ffc72c40"}
+//	]
+//
+// Colors are written #rrggbb, or #rrggbbaa with transparency, or the short #rgb.
+// The background and foreground must be given. Without a focusbar, it's the usual red,
+// the highlight is the foreground, and notes are the foreground faded.
+// A theme with the name of a built-in theme replaces it. C goes through them all, in order.
+
+// High contrast, yellow on black
+var colorContrast = colorMode{
+	name:       "contrast",
+	background: color.NRGBA{A: 0xff},
+	foreground: color.NRGBA{R: 0xff, G: 0xee, B: 0x00, A: 0xff},
+	focusbar:   color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x33},
+	highlight:  color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	note:       color.NRGBA{R: 0xff, G: 0xee, B: 0x00, A: 0x80},
+}
+
+// Green on black, dim enough for a dark set
+var colorNight = colorMode{
+	name:       "night",
+	background: color.NRGBA{A: 0xff},
+	foreground: color.NRGBA{R: 0x00, G: 0xb0, B: 0x40, A: 0xff},
+	focusbar:   color.NRGBA{R: 0x00, G: 0xff, B: 0x60, A: 0x22},
+	highlight:  color.NRGBA{R: 0x40, G: 0xff, B: 0x80, A: 0xff},
+	note:       color.NRGBA{R: 0x00, G: 0xb0, B: 0x40, A: 0x55},
+}
+
+// themeConfig is a theme as written in the preferences file
+type themeConfig struct {
+	Name       string `json:"name"`
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Focusbar   string `json:"focusbar,omitempty"`
+	Highlight  string `json:"highlight,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// parseColor reads a color written #rgb, #rrggbb or #rrggbbaa
+func parseColor(s string) (color.NRGBA, error) {
+	hex, ok := strings.CutPrefix(strings.TrimSpace(s), "#")
+	if !ok {
+		return color.NRGBA{}, fmt.Errorf("%q is not a color, it must start with #, like #ffcc00", s)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%q is not a color, use #rgb, #rrggbb or #rrggbbaa", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%q is not a color, only 0-9 and a-f can follow the #", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// colorMode reads the colors of a theme, and fills in those left out
+func (t themeConfig) colorMode() (colorMode, error) {
+	c := colorMode{name: t.Name, focusbar: colorDark.focusbar}
+	problems := []error{}
+	for _, field := range []struct {
+		name     string
+		value    string
+		into     *color.NRGBA
+		required bool
+	}{
+		{"background", t.Background, &c.background, true},
+		{"foreground", t.Foreground, &c.foreground, true},
+		{"focusbar", t.Focusbar, &c.focusbar, false},
+		{"highlight", t.Highlight, &c.highlight, false},
+		{"note", t.Note, &c.note, false},
+	} {
+		if field.value == "" {
+			if field.required {
+				problems = append(problems, fmt.Errorf("%s is missing", field.name))
+			}
+			continue
+		}
+		col, err := parseColor(field.value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", field.name, err))
+			continue
+		}
+		*field.into = col
+	}
+	if t.Highlight == "" {
+		c.highlight = c.foreground
+	}
+	if t.Note == "" {
+		c.note = c.foreground
+		c.note.A = c.foreground.A / 3
+	}
+	return c, errors.Join(problems...)
+}
+
+// buildThemes lists the built-in themes, followed by those from the preferences.
+// All the problems with the themes are reported together.
+func buildThemes(custom []themeConfig) ([]colorMode, error) {
+	themes := []colorMode{colorDark, colorLight, colorContrast, colorNight}
+	problems := []string{}
+	seen := map[string]bool{}
+	for i, t := range custom {
+		if strings.TrimSpace(t.Name) == "" {
+			problems = append(problems, fmt.Sprintf("theme %d has no name", i+1))
+			continue
+		}
+		c, err := t.colorMode()
+		if err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				problems = append(problems, fmt.Sprintf("theme %q: %s", t.Name, line))
+			}
+			continue
+		}
+		if seen[t.Name] {
+			problems = append(problems, fmt.Sprintf("theme %q is there twice", t.Name))
+			continue
+		}
+		seen[t.Name] = true
+		if at, found := findTheme(themes, t.Name); found {
+			themes[at] = c
+		} else {
+			themes = append(themes, c)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("themes:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return themes, nil
+}
+
+// findTheme finds a theme by name
+func findTheme(themes []colorMode, name string) (int, bool) {
+	for i, t := range themes {
+		if t.name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// themeNames lists the names of the themes, for error messages
+func themeNames(themes []colorMode) string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// nextTheme switches to the next color theme, and after the last back to the first
+func (p *prompter) nextTheme() {
+	at, _ := findTheme(themes, p.color.name)
+	p.color = themes[(at+1)%len(themes)]
+	fmt.Printf("THEME : %s\n", p.color.name)
+}
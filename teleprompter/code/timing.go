@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Timing report.
+// While autoscrolling, we keep track of how long each paragraph is under the focus bar.
+// Press T to print a report, or use -report to save it as CSV and as a text table when the prompter closes.
+// With a playlist, each script gets a report of its own.
+
+// How many words of each paragraph to show in the report
+const reportWords = 6
+
+// paragraphTiming keeps the seconds each paragraph has spent under the focus bar
+type paragraphTiming struct {
+	seconds []float64
+	// When we last counted
+	last time.Time
+}
+
+// track adds the time since last frame to the paragraph under the focus bar.
+// Only time spent autoscrolling counts, so the report isn't thrown off by breaks.
+func (t *paragraphTiming) track(now time.Time, current int, running bool) {
+	if !running || current < 0 || current >= len(t.seconds) {
+		t.last = time.Time{}
+		return
+	}
+	if !t.last.IsZero() {
+		t.seconds[current] += now.Sub(t.last).Seconds()
+	}
+	t.last = now
+}
+
+// remap keeps the times when the speech is reloaded.
+// Paragraphs are matched on their text, so lines added above don't mix up the times.
+func (t *paragraphTiming) remap(old, new []paragraph) {
+	seconds := make([]float64, len(new))
+	for i := range new {
+		if j := findMatch(old, new[i], i); j >= 0 && j < len(t.seconds) {
+			seconds[i] = t.seconds[j]
+		}
+	}
+	t.seconds = seconds
+}
+
+// timingRow is one line of the report
+type timingRow struct {
+	paragraph  int
+	firstWords string
+	words      int
+	seconds    float64
+	// Words per minute, or 0 if the paragraph was never under the focus bar
+	wpm float64
+}
+
+// timingReport lists the paragraphs with something to say, or which took some time
+func timingReport(paragraphs []paragraph, seconds []float64) []timingRow {
+	rows := []timingRow{}
+	for i, p := range paragraphs {
+		words := strings.Fields(p.spoken())
+		var spent float64
+		if i < len(seconds) {
+			spent = seconds[i]
+		}
+		if len(words) == 0 && spent < 0.05 {
+			continue
+		}
+		row := timingRow{paragraph: i + 1, words: len(words), seconds: spent}
+		row.firstWords = strings.Join(words[:min(len(words), reportWords)], " ")
+		if len(words) > reportWords {
+			row.firstWords += " ..."
+		}
+		if spent > 0 {
+			row.wpm = float64(len(words)) / spent * 60
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// writeTimingCSV writes the report as CSV, for spreadsheets
+func writeTimingCSV(w io.Writer, rows []timingRow) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"paragraph", "firstWords", "words", "seconds", "wpm"})
+	for _, r := range rows {
+		out.Write([]string{
+			strconv.Itoa(r.paragraph),
+			r.firstWords,
+			strconv.Itoa(r.words),
+			strconv.FormatFloat(r.seconds, 'f', 1, 64),
+			strconv.FormatFloat(r.wpm, 'f', 0, 64),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeTimingTable writes the report as a table, for people
+func writeTimingTable(w io.Writer, rows []timingRow) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tFirst words\tWords\tSeconds\tWPM\t")
+	var words int
+	var seconds float64
+	for _, r := range rows {
+		wpm := "-"
+		if r.wpm > 0 {
+			wpm = fmt.Sprintf("%.0f", r.wpm)
+		}
+		fmt.Fprintf(table, "%d\t%s\t%d\t%.1f\t%s\t\n", r.paragraph, r.firstWords, r.words, r.seconds, wpm)
+		words += r.words
+		seconds += r.seconds
+	}
+	total := "-"
+	if seconds > 0 {
+		total = fmt.Sprintf("%.0f", float64(words)/seconds*60)
+	}
+	fmt.Fprintf(table, "\tTotal\t%d\t%.1f\t%s\t\n", words, seconds, total)
+	return table.Flush()
+}
+
+// segmentReportName is where the report of a script in a playlist is saved,
+// like report-2-weather.csv for weather.txt, the second script
+func segmentReportName(name string, index int, s *script) string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	segment := strings.TrimSuffix(s.name(), filepath.Ext(s.name()))
+	return fmt.Sprintf("%s-%d-%s.csv", base, index+1, segment)
+}
+
+// saveTimingReport writes the report next to each other as name.csv and name.txt
+func saveTimingReport(name string, rows []timingRow) error {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	for ext, write := range map[string]func(io.Writer, []timingRow) error{
+		".csv": writeTimingCSV,
+		".txt": writeTimingTable,
+	} {
+		f, err := os.Create(name + ext)
+		if err != nil {
+			return err
+		}
+		if err := write(f, rows); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
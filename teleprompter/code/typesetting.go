@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gioui.org/text"
+)
+
+// Typesetting.
+// Some read more easily with the text to the left, looser lines or a gap between paragraphs.
+// A switches between start, middle and end alignment. Alt+Up and Alt+Down make the lines looser and tighter,
+// and Alt+Right and Alt+Left widen and narrow the gap between paragraphs.
+// All three can be set with -align, -lineheight and -spacing, and are kept in the preferences.
+// Since the position is a paragraph and a fraction into it, the text at the focus bar stays put when they change.
+
+// Limits for the line height, as a multiple of the usual line height,
+// and for the space after each paragraph, in font sizes
+const (
+	minLineHeight  = 0.7
+	maxLineHeight  = 3
+	lineHeightStep = 0.1
+	maxSpacing     = 3
+	spacingStep    = 0.1
+)
+
+// Gio spaces lines at 1.2 times the font size, unless told otherwise
+const normalLineHeight = 1.2
+
+// paragraphStyle is how the paragraphs are set
+type paragraphStyle struct {
+	alignment text.Alignment
+	// A multiple of the usual line height
+	lineHeight float32
+	// The space after each paragraph, in font sizes
+	spacing float32
+}
+
+// Names of the alignments, as written in -align and the preferences
+var alignmentNames = map[string]text.Alignment{
+	"start":  text.Start,
+	"middle": text.Middle,
+	"end":    text.End,
+}
+
+// parseAlignment reads an alignment, start, middle or end
+func parseAlignment(s string) (text.Alignment, error) {
+	if a, ok := alignmentNames[strings.ToLower(s)]; ok {
+		return a, nil
+	}
+	return text.Middle, fmt.Errorf("alignment must be start, middle or end, not %q", s)
+}
+
+// alignmentName is the name of an alignment, for the preferences
+func alignmentName(a text.Alignment) string {
+	for name, alignment := range alignmentNames {
+		if alignment == a {
+			return name
+		}
+	}
+	return "middle"
+}
+
+// nextAlignment goes from start to middle to end, and round again
+func (s *paragraphStyle) nextAlignment() {
+	switch s.alignment {
+	case text.Start:
+		s.alignment = text.Middle
+	case text.Middle:
+		s.alignment = text.End
+	default:
+		s.alignment = text.Start
+	}
+	fmt.Printf("ALIGN : %s\n", alignmentName(s.alignment))
+}
+
+// changeLineHeight makes the lines looser, or tighter with a negative number of steps
+func (s *paragraphStyle) changeLineHeight(steps float32) {
+	s.lineHeight = min(max(s.lineHeight+steps*lineHeightStep, minLineHeight), maxLineHeight)
+	fmt.Printf("LINES : %.1f\n", s.lineHeight)
+}
+
+// changeSpacing widens the gap between paragraphs, or narrows it with a negative number of steps
+func (s *paragraphStyle) changeSpacing(steps float32) {
+	s.spacing = min(max(s.spacing+steps*spacingStep, 0), maxSpacing)
+	fmt.Printf("SPACE : %.1f\n", s.spacing)
+}
+
+// lineHeightScale is the line height for a label
+func (s paragraphStyle) lineHeightScale() float32 {
+	return normalLineHeight * s.lineHeight
+}